@@ -0,0 +1,104 @@
+package wpa
+
+import (
+	"fmt"
+	"strings"
+)
+
+// blacklistCommand returns the name of the BSSID ignore list command.
+// wpa_supplicant 2.10 renamed BLACKLIST to BSSID_IGNORE; the first call
+// probes for it and the result is cached.
+func (c *WPASupplicantCtrl) blacklistCommand() (string, error) {
+	c.mu.Lock()
+	cmd := c.blacklistCmd
+	c.mu.Unlock()
+	if cmd != "" {
+		return cmd, nil
+	}
+	rsp, err := c.ctrl.Command("BSSID_IGNORE")
+	if err != nil {
+		return "", err
+	}
+	cmd = "BSSID_IGNORE"
+	if strings.HasPrefix(rsp, "UNKNOWN") {
+		cmd = "BLACKLIST"
+	}
+	c.mu.Lock()
+	c.blacklistCmd = cmd
+	c.mu.Unlock()
+	return cmd, nil
+}
+
+// BlacklistAdd stops wpa_supplicant from selecting bssid until it is removed
+// or the list is cleared.
+func (c *WPASupplicantCtrl) BlacklistAdd(bssid MAC) error {
+	cmd, err := c.blacklistCommand()
+	if err != nil {
+		return err
+	}
+	return c.ctrl.OkCommand(fmt.Sprintf("%s %s", cmd, bssid))
+}
+
+// BlacklistRemove removes bssid from the list. wpa_supplicant has no command
+// to remove a single entry, so the list is cleared and the other entries are
+// added back, which resets their counts.
+func (c *WPASupplicantCtrl) BlacklistRemove(bssid MAC) error {
+	list, err := c.BlacklistList()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, m := range list {
+		found = found || m == bssid
+	}
+	if !found {
+		return nil
+	}
+	if err := c.BlacklistClear(); err != nil {
+		return err
+	}
+	for _, m := range list {
+		if m == bssid {
+			continue
+		}
+		if err := c.BlacklistAdd(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BlacklistClear empties the list, including the entries wpa_supplicant added
+// itself.
+func (c *WPASupplicantCtrl) BlacklistClear() error {
+	cmd, err := c.blacklistCommand()
+	if err != nil {
+		return err
+	}
+	return c.ctrl.OkCommand(cmd + " clear")
+}
+
+// BlacklistList returns the ignored BSSIDs. This includes the ones
+// wpa_supplicant added itself after connection failures.
+func (c *WPASupplicantCtrl) BlacklistList() ([]MAC, error) {
+	cmd, err := c.blacklistCommand()
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := c.ctrl.FailCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var list []MAC
+	for _, line := range strings.Split(rsp, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		m, err := ParseMAC(line)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s entry %q", cmd, line)
+		}
+		list = append(list, m)
+	}
+	return list, nil
+}
@@ -0,0 +1,62 @@
+package wpa
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testBlacklist(t *testing.T, ctrl *WPASupplicantCtrl) {
+	a := MustParseMAC("02:00:00:00:01:00")
+	b := MustParseMAC("02:00:00:00:02:00")
+	c := MustParseMAC("02:00:00:00:03:00")
+
+	list, err := ctrl.BlacklistList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Fatal("expected empty list", list)
+	}
+	for _, m := range []MAC{a, b, c} {
+		if err := ctrl.BlacklistAdd(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ctrl.BlacklistRemove(b); err != nil {
+		t.Fatal(err)
+	}
+	list, err = ctrl.BlacklistList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(list, []MAC{a, c}) {
+		t.Fatal("wrong list", list)
+	}
+	if err := ctrl.BlacklistClear(); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := ctrl.BlacklistList(); len(list) != 0 {
+		t.Fatal("list not cleared", list)
+	}
+}
+
+func TestBSSIDIgnore(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	testBlacklist(t, ctrl)
+
+	mock.Expect("BSSID_IGNORE 02:00:00:00:01:00", "OK")
+	if err := ctrl.BlacklistAdd(MustParseMAC("02:00:00:00:01:00")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBlacklistLegacy(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	mock.SetLegacyBlacklist(true)
+	testBlacklist(t, ctrl)
+
+	mock.Expect("BLACKLIST 02:00:00:00:01:00", "OK")
+	if err := ctrl.BlacklistAdd(MustParseMAC("02:00:00:00:01:00")); err != nil {
+		t.Fatal(err)
+	}
+}
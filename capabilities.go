@@ -0,0 +1,237 @@
+package wpa
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChannelInfo is a channel from GET_CAPABILITY freq.
+type ChannelInfo struct {
+	// Mode is the hardware mode, e.g. B, G, A or AD.
+	Mode    string
+	Channel int
+	Freq    int
+	// NoIR is set when the AP can't initiate radiation, so the channel can't be
+	// used for an AP or active scanning.
+	NoIR bool
+	DFS  bool
+}
+
+// Capabilities is what the driver and wpa_supplicant build support, as reported
+// by GET_CAPABILITY. Fields the build doesn't report are empty.
+type Capabilities struct {
+	EAP      []string
+	Pairwise []string
+	Group    []string
+	KeyMgmt  []string
+	Proto    []string
+	AuthAlg  []string
+	// Modes are the supported operating modes besides station: IBSS, AP and
+	// MESH.
+	Modes []string
+	// Channels are the channel numbers by hardware mode.
+	Channels map[string][]int
+	Freqs    []ChannelInfo
+	// TDLS is EXTERNAL, INTERNAL or UNSUPPORTED.
+	TDLS string
+	ERP  bool
+	FIPS bool
+	ACS  bool
+	// SAE lists the SAE features, e.g. H2E and PK.
+	SAE []string
+	// P2P is set when STATUS reports a p2p_device_address, which it only does
+	// when wpa_supplicant is built with P2P and P2P is enabled. GET_CAPABILITY
+	// doesn't report P2P support.
+	P2P bool
+}
+
+// Capabilities queries every GET_CAPABILITY field. A FAIL reply means the field
+// isn't supported by the build, and leaves it empty.
+func (c *WPASupplicantCtrl) Capabilities() (*Capabilities, error) {
+	get := func(field string) (string, error) {
+		rsp, err := c.ctrl.Command("GET_CAPABILITY " + field)
+		if err != nil {
+			return "", fmt.Errorf("get capability %s: %v", field, err)
+		}
+		if rsp == "FAIL" {
+			return "", nil
+		}
+		return rsp, nil
+	}
+	caps := &Capabilities{}
+	for _, l := range []struct {
+		field string
+		dst   *[]string
+	}{
+		{"eap", &caps.EAP},
+		{"pairwise", &caps.Pairwise},
+		{"group", &caps.Group},
+		{"key_mgmt", &caps.KeyMgmt},
+		{"proto", &caps.Proto},
+		{"auth_alg", &caps.AuthAlg},
+		{"modes", &caps.Modes},
+		{"sae", &caps.SAE},
+	} {
+		rsp, err := get(l.field)
+		if err != nil {
+			return nil, err
+		}
+		*l.dst = strings.Fields(rsp)
+	}
+
+	rsp, err := get("channels")
+	if err != nil {
+		return nil, err
+	}
+	caps.Channels = parseCapabilityChannels(rsp)
+	if rsp, err = get("freq"); err != nil {
+		return nil, err
+	}
+	caps.Freqs = parseCapabilityFreqs(rsp)
+
+	if rsp, err = get("tdls"); err != nil {
+		return nil, err
+	}
+	caps.TDLS = strings.TrimSpace(rsp)
+	for _, f := range []struct {
+		field string
+		dst   *bool
+	}{
+		{"erp", &caps.ERP},
+		{"fips", &caps.FIPS},
+		{"acs", &caps.ACS},
+	} {
+		if rsp, err = get(f.field); err != nil {
+			return nil, err
+		}
+		*f.dst = strings.TrimSpace(rsp) != ""
+	}
+
+	status, err := c.ctrl.Command("STATUS")
+	if err != nil {
+		return nil, fmt.Errorf("status: %v", err)
+	}
+	caps.P2P = parseKeyValues(status)["p2p_device_address"] != ""
+	return caps, nil
+}
+
+// parseCapabilityMode parses a "Mode[G] Channels:" header, returning the mode
+// and the rest of the line.
+func parseCapabilityMode(line string) (string, string, bool) {
+	if !strings.HasPrefix(line, "Mode[") {
+		return "", "", false
+	}
+	end := strings.IndexByte(line, ']')
+	if end < 0 {
+		return "", "", false
+	}
+	rest := strings.TrimPrefix(strings.TrimSpace(line[end+1:]), "Channels:")
+	return line[5:end], rest, true
+}
+
+// parseCapabilityChannels parses lines of the form "Mode[G] Channels: 1 2 3".
+func parseCapabilityChannels(rsp string) map[string][]int {
+	channels := map[string][]int{}
+	for _, line := range strings.Split(rsp, "\n") {
+		mode, rest, ok := parseCapabilityMode(line)
+		if !ok {
+			continue
+		}
+		for _, ch := range strings.Fields(rest) {
+			channels[mode] = append(channels[mode], atoi(ch))
+		}
+	}
+	return channels
+}
+
+// parseCapabilityFreqs parses a "Mode[A] Channels:" header followed by lines
+// like " 52 = 5260 MHz (DFS)".
+func parseCapabilityFreqs(rsp string) []ChannelInfo {
+	var freqs []ChannelInfo
+	mode := ""
+	for _, line := range strings.Split(rsp, "\n") {
+		if m, _, ok := parseCapabilityMode(line); ok {
+			mode = m
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) < 4 || f[1] != "=" || f[3] != "MHz" {
+			continue
+		}
+		freqs = append(freqs, ChannelInfo{
+			Mode:    mode,
+			Channel: atoi(f[0]),
+			Freq:    atoi(f[2]),
+			NoIR:    strings.Contains(line, "(NO_IR)"),
+			DFS:     strings.Contains(line, "(DFS)"),
+		})
+	}
+	return freqs
+}
+
+func (c *Capabilities) HasEAP(method string) bool {
+	return hasAny(c.EAP, method)
+}
+
+func (c *Capabilities) HasKeyMgmt(keyMgmt string) bool {
+	return hasAny(c.KeyMgmt, keyMgmt)
+}
+
+func (c *Capabilities) HasPairwise(cipher string) bool {
+	return hasAny(c.Pairwise, cipher)
+}
+
+// SupportsWPA3 reports whether SAE is supported with management frame
+// protection capable ciphers.
+func (c *Capabilities) SupportsWPA3() bool {
+	return c.HasKeyMgmt("SAE") && (c.HasPairwise("CCMP") || c.HasPairwise("GCMP"))
+}
+
+// SupportsSAEH2E reports whether SAE hash-to-element is supported, which is
+// required for SAE on 6 GHz and for SAE-EXT-KEY.
+func (c *Capabilities) SupportsSAEH2E() bool {
+	return hasAny(c.SAE, "H2E")
+}
+
+func (c *Capabilities) SupportsOWE() bool {
+	return c.HasKeyMgmt("OWE")
+}
+
+func (c *Capabilities) SupportsAP() bool {
+	return hasAny(c.Modes, "AP")
+}
+
+func (c *Capabilities) SupportsMesh() bool {
+	return hasAny(c.Modes, "MESH")
+}
+
+func (c *Capabilities) SupportsP2P() bool {
+	return c.P2P
+}
+
+func (c *Capabilities) SupportsTDLS() bool {
+	return c.TDLS != "" && c.TDLS != "UNSUPPORTED"
+}
+
+func (c *Capabilities) hasFreq(min, max int) bool {
+	for _, f := range c.Freqs {
+		if f.Freq >= min && f.Freq <= max {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Capabilities) Supports2GHz() bool {
+	return c.hasFreq(2412, 2484)
+}
+
+func (c *Capabilities) Supports5GHz() bool {
+	return c.hasFreq(5150, 5925)
+}
+
+// Supports6GHz reports whether any 6 GHz channel is available. Connecting on
+// 6 GHz also needs WPA3 with hash-to-element, or OWE.
+func (c *Capabilities) Supports6GHz() bool {
+	return c.hasFreq(5935, 7125)
+}
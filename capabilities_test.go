@@ -0,0 +1,53 @@
+package wpa
+
+import "testing"
+
+func TestCapabilities(t *testing.T) {
+	_, ctrl := NewWPASupplicantTest(t)
+
+	caps, err := ctrl.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.HasEAP("PEAP") || !caps.HasEAP("AKA'") || caps.HasEAP("TEAP") {
+		t.Fatal("wrong eap", caps.EAP)
+	}
+	if !caps.SupportsWPA3() || !caps.SupportsSAEH2E() || !caps.SupportsOWE() {
+		t.Fatalf("expected WPA3 support %+v", caps)
+	}
+	if caps.HasKeyMgmt("SAE-EXT-KEY") {
+		t.Fatal("unexpected SAE-EXT-KEY")
+	}
+	if !caps.SupportsAP() || !caps.SupportsMesh() || !caps.SupportsP2P() || !caps.SupportsTDLS() {
+		t.Fatal("wrong modes", caps.Modes, caps.TDLS)
+	}
+	if hasAny(caps.Modes, "P2P") {
+		t.Fatal("modes never include P2P", caps.Modes)
+	}
+	if caps.ERP || caps.FIPS || !caps.ACS {
+		t.Fatalf("wrong flags %+v", caps)
+	}
+	if len(caps.Channels["G"]) != 11 || len(caps.Channels["A"]) != 16 {
+		t.Fatal("wrong channels", caps.Channels)
+	}
+	if !caps.Supports2GHz() || !caps.Supports5GHz() || !caps.Supports6GHz() {
+		t.Fatal("wrong bands", caps.Freqs)
+	}
+	if len(caps.Freqs) != 8 {
+		t.Fatal("wrong freqs", caps.Freqs)
+	}
+	dfs := caps.Freqs[4]
+	if dfs != (ChannelInfo{Mode: "A", Channel: 52, Freq: 5260, DFS: true}) {
+		t.Fatalf("wrong channel %+v", dfs)
+	}
+	if !caps.Freqs[6].NoIR {
+		t.Fatalf("expected NO_IR %+v", caps.Freqs[6])
+	}
+}
+
+func TestCapabilitiesEmpty(t *testing.T) {
+	caps := &Capabilities{}
+	if caps.SupportsWPA3() || caps.SupportsTDLS() || caps.SupportsP2P() || caps.Supports6GHz() {
+		t.Fatal("expected no capabilities")
+	}
+}
@@ -0,0 +1,84 @@
+// Command wpa-proxy serves a wpa_supplicant or hostapd control socket over TCP.
+//
+// With -tls-cert, -tls-key and -tls-ca, clients must present a certificate
+// signed by the CA, and are identified by its common name. Without them the
+// proxy listens on plain TCP, which should only be bound to localhost and
+// reached through an SSH forward; every client is then "anonymous".
+//
+// The policy file maps identities to the commands they may run:
+//
+//	{"operator": ["STATUS", "SCAN", "SCAN_RESULTS", "ATTACH", "DETACH"], "admin": ["*"]}
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net"
+
+	"github.com/jblebrun/go-wpa/conn"
+	"github.com/jblebrun/go-wpa/proxy"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:9878", "address to listen on")
+	ctrlIface := flag.String("ctrl-interface", conn.DefaultSupplicantDir, "ctrl_interface directory or DIR=... setting")
+	iface := flag.String("iface", "wlan0", "interface (socket name) to proxy")
+	policyFile := flag.String("policy", "", "JSON file mapping identities to allowed commands")
+	certFile := flag.String("tls-cert", "", "server certificate")
+	keyFile := flag.String("tls-key", "", "server key")
+	caFile := flag.String("tls-ca", "", "CA for client certificates")
+	flag.Parse()
+
+	if *policyFile == "" {
+		log.Fatal("-policy is required")
+	}
+	policy, err := loadPolicy(*policyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ci, err := conn.ParseCtrlInterface(*ctrlIface)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *certFile != "" || *keyFile != "" || *caFile != "" {
+		cfg, err := proxy.ServerTLSConfig(*certFile, *keyFile, *caFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		l = tls.NewListener(l, cfg)
+	}
+
+	s := &proxy.Server{
+		Dial: func() (conn.Conn, error) {
+			return ci.Dial(*iface)
+		},
+		Policy: policy,
+	}
+	log.Printf("proxying %s on %s", ci.Path(*iface), l.Addr())
+	log.Fatal(s.Serve(l))
+}
+
+func loadPolicy(path string) (map[string]proxy.AllowList, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string][]string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	policy := make(map[string]proxy.AllowList)
+	for id, cmds := range raw {
+		policy[id] = proxy.NewAllowList(cmds...)
+	}
+	return policy, nil
+}
@@ -6,7 +6,12 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"os/user"
 	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
 )
 
 // Conn is an abstract of a unix datagram connection so that a test mock can be probided
@@ -44,32 +49,231 @@ func NewUnixListen(endpoint, iface string) (ListenConn, error) {
 
 // NewUnixConn creates a unix datagram socket sender with a localaddress attached the
 // the receiver can use for sending responses.
+// The local socket is created according to DefaultUnixConnConfig.
 func NewUnixConn(endpoint, iface string) (Conn, error) {
-	f, err := ioutil.TempFile("", fmt.Sprintf("wpactrl-%s", iface))
-	if err != nil {
-		return nil, err
+	return DefaultUnixConnConfig.Dial(endpoint, iface)
+}
+
+// UnixConnConfig controls where the client end of a unix datagram connection
+// is bound.
+type UnixConnConfig struct {
+	// Dir holds the client sockets. Defaults to os.TempDir().
+	Dir string
+	// Perm is applied to the client socket, so that a server running as a
+	// different user can respond. Zero leaves the umask default.
+	Perm os.FileMode
+	// Group, a name or numeric id, owns the client socket if set, like the
+	// GROUP of a ctrl_interface.
+	Group string
+	// RemoveStale removes client sockets left behind in Dir by dead processes
+	// before binding.
+	RemoveStale bool
+}
+
+var DefaultUnixConnConfig = UnixConnConfig{}
+
+const clientPrefix = "wpactrl-"
+
+var clientCounter uint32
+
+// Dial connects to the socket for iface in the endpoint directory.
+// The client socket is removed when the returned Conn is closed.
+func (cfg UnixConnConfig) Dial(endpoint, iface string) (Conn, error) {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if cfg.RemoveStale {
+		if err := RemoveStaleSockets(dir); err != nil {
+			return nil, err
+		}
 	}
-	os.Remove(f.Name())
-	f.Close()
 
-	recvEndpoint := f.Name()
+	recvEndpoint := path.Join(dir, fmt.Sprintf("%s%s-%d-%d",
+		clientPrefix, iface, os.Getpid(), atomic.AddUint32(&clientCounter, 1)))
+	// a previous process with the same pid may have left this behind
 	os.Remove(recvEndpoint)
 
 	wpaEndpoint := path.Join(endpoint, iface)
 
-	return net.DialUnix("unixgram",
+	c, err := net.DialUnix("unixgram",
 		&net.UnixAddr{Name: recvEndpoint},
 		&net.UnixAddr{Name: wpaEndpoint},
 	)
+	if err != nil {
+		os.Remove(recvEndpoint)
+		return nil, err
+	}
+	if err := cfg.setOwner(recvEndpoint); err != nil {
+		c.Close()
+		os.Remove(recvEndpoint)
+		return nil, err
+	}
+	return &unixConn{UnixConn: c, local: recvEndpoint}, nil
 }
 
-// Get will return a connection to respond on
+// setOwner applies Group and Perm to the client socket.
+func (cfg UnixConnConfig) setOwner(p string) error {
+	if cfg.Group != "" {
+		gid, err := lookupGroup(cfg.Group)
+		if err != nil {
+			return err
+		}
+		if err := os.Chown(p, -1, gid); err != nil {
+			return err
+		}
+	}
+	if cfg.Perm != 0 {
+		return os.Chmod(p, cfg.Perm)
+	}
+	return nil
+}
+
+// lookupGroup returns the id of a group given by name or number.
+func lookupGroup(group string) (int, error) {
+	if gid, err := strconv.Atoi(group); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// unixConn removes its local socket file on Close.
+type unixConn struct {
+	*net.UnixConn
+	local string
+}
+
+func (c *unixConn) Close() error {
+	err := c.UnixConn.Close()
+	os.Remove(c.local)
+	return err
+}
+
+// RemoveStaleSockets removes client sockets in dir that nothing is bound to.
+// Live client sockets are connected to their server, so connecting to them
+// fails with EPERM; stale ones fail with ECONNREFUSED.
+func RemoveStaleSockets(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), clientPrefix) || e.Mode()&os.ModeSocket == 0 {
+			continue
+		}
+		p := path.Join(dir, e.Name())
+		c, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: p})
+		if err == nil {
+			c.Close()
+			continue
+		}
+		if isConnRefused(err) {
+			os.Remove(p)
+		}
+	}
+	return nil
+}
+
+func isConnRefused(err error) bool {
+	if oe, ok := err.(*net.OpError); ok {
+		if se, ok := oe.Err.(*os.SyscallError); ok {
+			return se.Err == syscall.ECONNREFUSED
+		}
+	}
+	return false
+}
+
+// CtrlInterface is a parsed ctrl_interface setting, as found in
+// wpa_supplicant.conf and hostapd.conf.
+type CtrlInterface struct {
+	Dir   string
+	Group string
+}
+
+// ParseCtrlInterface parses either a plain directory, or the
+// "DIR=/var/run/wpa_supplicant GROUP=netdev" form.
+func ParseCtrlInterface(s string) (CtrlInterface, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "DIR=") {
+		if s == "" {
+			return CtrlInterface{}, errors.New("empty ctrl_interface")
+		}
+		return CtrlInterface{Dir: s}, nil
+	}
+
+	var ci CtrlInterface
+	for _, f := range strings.Fields(s) {
+		switch {
+		case strings.HasPrefix(f, "DIR="):
+			ci.Dir = f[len("DIR="):]
+		case strings.HasPrefix(f, "GROUP="):
+			ci.Group = f[len("GROUP="):]
+		default:
+			return CtrlInterface{}, fmt.Errorf("unknown ctrl_interface field %q", f)
+		}
+	}
+	if ci.Dir == "" {
+		return CtrlInterface{}, errors.New("ctrl_interface has no DIR")
+	}
+	return ci, nil
+}
+
+// Path returns the server socket for iface.
+func (ci CtrlInterface) Path(iface string) string {
+	return path.Join(ci.Dir, iface)
+}
+
+// ConnConfig returns the UnixConnConfig for clients of ci. With a GROUP, the
+// client sockets belong to that group and are group writable, so a server
+// running as the group can respond.
+func (ci CtrlInterface) ConnConfig() UnixConnConfig {
+	cfg := DefaultUnixConnConfig
+	if ci.Group != "" {
+		cfg.Group = ci.Group
+		if cfg.Perm == 0 {
+			cfg.Perm = 0660
+		}
+	}
+	return cfg
+}
+
+// Dial connects to the server socket for iface, using ConnConfig.
+func (ci CtrlInterface) Dial(iface string) (Conn, error) {
+	return ci.ConnConfig().Dial(ci.Dir, iface)
+}
+
+// Get will return a connection to respond on.
+// Responses have to come from the listening socket itself, since the client's
+// socket is connected to it and will refuse datagrams from anywhere else.
 func (uc *unixListenConn) Get(addr net.Addr) (Conn, error) {
 	uaddr, ok := addr.(*net.UnixAddr)
 	if !ok {
 		return nil, errors.New("need *net.UnixAddr")
 	}
-	return net.DialUnix("unixgram", nil, uaddr)
+	return &replyConn{uc.UnixConn, uaddr}, nil
+}
+
+// replyConn sends responses to a single client address from the listening socket.
+type replyConn struct {
+	*net.UnixConn
+	addr *net.UnixAddr
+}
+
+func (rc *replyConn) Write(b []byte) (int, error) {
+	return rc.UnixConn.WriteToUnix(b, rc.addr)
+}
+
+func (rc *replyConn) Read([]byte) (int, error) {
+	return 0, errors.New("reply only")
+}
+
+// Close is a no-op; the listening socket is owned by the ListenConn.
+func (rc *replyConn) Close() error {
+	return nil
 }
 
 // Dial is used for testing, this returns a connection to the listening connection
@@ -0,0 +1,145 @@
+package conn
+
+import (
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestUnixConnClientDir(t *testing.T) {
+	srvDir := tempDir(t)
+	defer os.RemoveAll(srvDir)
+	clientDir := tempDir(t)
+	defer os.RemoveAll(clientDir)
+
+	defer serve(t, srvDir, "wlan0", "PONG").Close()
+
+	cfg := UnixConnConfig{Dir: clientDir, Perm: 0660}
+	c, err := cfg.Dial(srvDir, "wlan0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Write([]byte("PING")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	n, err := c.Read(buf)
+	if err != nil || string(buf[:n]) != "PONG" {
+		t.Fatal("bad response", string(buf[:n]), err)
+	}
+
+	local := c.(*unixConn).local
+	if path.Dir(local) != clientDir {
+		t.Fatal("client socket in wrong dir", local)
+	}
+	fi, err := os.Stat(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0660 {
+		t.Fatal("wrong perm", fi.Mode())
+	}
+
+	c.Close()
+	if _, err := os.Stat(local); !os.IsNotExist(err) {
+		t.Fatal("client socket not removed", err)
+	}
+}
+
+func TestCtrlInterfaceDial(t *testing.T) {
+	srvDir := tempDir(t)
+	defer os.RemoveAll(srvDir)
+
+	defer serve(t, srvDir, "wlan0", "PONG").Close()
+
+	gid := os.Getgid()
+	ci := CtrlInterface{Dir: srvDir, Group: strconv.Itoa(gid)}
+	c, err := ci.Dial("wlan0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.Write([]byte("PING")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	if n, err := c.Read(buf); err != nil || string(buf[:n]) != "PONG" {
+		t.Fatal("bad response", string(buf[:n]), err)
+	}
+
+	fi, err := os.Stat(c.(*unixConn).local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0660 {
+		t.Fatal("wrong perm", fi.Mode())
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && int(st.Gid) != gid {
+		t.Fatal("wrong group", st.Gid)
+	}
+
+	if _, err := (CtrlInterface{Dir: srvDir, Group: "no-such-group"}).Dial("wlan0"); err == nil {
+		t.Fatal("expected error for unknown group")
+	}
+}
+
+func TestRemoveStaleSockets(t *testing.T) {
+	srvDir := tempDir(t)
+	defer os.RemoveAll(srvDir)
+	clientDir := tempDir(t)
+	defer os.RemoveAll(clientDir)
+
+	defer serve(t, srvDir, "wlan0", "PONG").Close()
+
+	stalePath := path.Join(clientDir, "wpactrl-wlan0-1-1")
+	stale, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: stalePath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.Close()
+
+	live, err := (UnixConnConfig{Dir: clientDir, RemoveStale: true}).Dial(srvDir, "wlan0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close()
+
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Fatal("stale socket not removed", err)
+	}
+	if err := RemoveStaleSockets(clientDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(live.(*unixConn).local); err != nil {
+		t.Fatal("live socket removed", err)
+	}
+}
+
+func TestParseCtrlInterface(t *testing.T) {
+	cases := map[string]CtrlInterface{
+		"/var/run/wpa_supplicant":                  {Dir: "/var/run/wpa_supplicant"},
+		"DIR=/var/run/wpa_supplicant GROUP=netdev": {Dir: "/var/run/wpa_supplicant", Group: "netdev"},
+		"  DIR=/run/hostapd  ":                     {Dir: "/run/hostapd"},
+	}
+	for in, expect := range cases {
+		ci, err := ParseCtrlInterface(in)
+		if err != nil {
+			t.Fatal(in, err)
+		}
+		if ci != expect {
+			t.Fatal("wrong parse", in, ci)
+		}
+	}
+	if ci, _ := ParseCtrlInterface("DIR=/var/run/wpa_supplicant"); ci.Path("wlan0") != "/var/run/wpa_supplicant/wlan0" {
+		t.Fatal("wrong path", ci.Path("wlan0"))
+	}
+
+	for _, bad := range []string{"", "DIR=/x FOO=bar", "DIR= GROUP=netdev"} {
+		if _, err := ParseCtrlInterface(bad); err == nil {
+			t.Fatal("expected error for", bad)
+		}
+	}
+}
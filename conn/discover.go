@@ -0,0 +1,177 @@
+package conn
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSupplicantDir = "/var/run/wpa_supplicant"
+	DefaultHostapdDir    = "/var/run/hostapd"
+)
+
+// Kind identifies what is listening on a control socket.
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindSupplicant
+	KindHostapd
+	KindGlobal
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindSupplicant:
+		return "wpa_supplicant"
+	case KindHostapd:
+		return "hostapd"
+	case KindGlobal:
+		return "global"
+	}
+	return "unknown"
+}
+
+// Socket is a control socket found by Discover.
+type Socket struct {
+	Dir  string
+	Name string
+	Kind Kind
+}
+
+func (s Socket) Path() string {
+	return path.Join(s.Dir, s.Name)
+}
+
+// Dial connects to the socket.
+func (s Socket) Dial() (Conn, error) {
+	return NewUnixConn(s.Dir, s.Name)
+}
+
+// Discovery finds control sockets in a set of directories.
+type Discovery struct {
+	// Dirs to search. Defaults to DefaultSupplicantDir and DefaultHostapdDir.
+	Dirs []string
+	// ProbeTimeout bounds the wait for a probe response. Defaults to one second.
+	ProbeTimeout time.Duration
+}
+
+// Discover finds the control sockets in dirs, or the default directories if none
+// are given.
+func Discover(dirs ...string) ([]Socket, error) {
+	d := &Discovery{Dirs: dirs}
+	return d.Discover()
+}
+
+func (d *Discovery) dirs() []string {
+	if len(d.Dirs) == 0 {
+		return []string{DefaultSupplicantDir, DefaultHostapdDir}
+	}
+	return d.Dirs
+}
+
+func (d *Discovery) probeTimeout() time.Duration {
+	if d.ProbeTimeout == 0 {
+		return time.Second
+	}
+	return d.ProbeTimeout
+}
+
+// Discover lists every responsive control socket. Missing directories are skipped,
+// as are sockets nothing is listening on.
+func (d *Discovery) Discover() ([]Socket, error) {
+	var sockets []Socket
+	for _, dir := range d.dirs() {
+		entries, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.Mode()&os.ModeSocket == 0 {
+				continue
+			}
+			kind, err := Probe(dir, e.Name(), d.probeTimeout())
+			if err != nil {
+				continue
+			}
+			sockets = append(sockets, Socket{Dir: dir, Name: e.Name(), Kind: kind})
+		}
+	}
+	return sockets, nil
+}
+
+type deadliner interface {
+	SetReadDeadline(time.Time) error
+}
+
+// Probe identifies the process behind a control socket by sending STATUS.
+// wpa_supplicant reports wpa_state and hostapd reports state. The
+// wpa_supplicant global interface reports its interfaces and P2P device
+// instead, and the hostapd global interface doesn't support STATUS at all.
+func Probe(dir, name string, timeout time.Duration) (Kind, error) {
+	c, err := NewUnixConn(dir, name)
+	if err != nil {
+		return KindUnknown, err
+	}
+	defer c.Close()
+
+	if d, ok := c.(deadliner); ok {
+		d.SetReadDeadline(time.Now().Add(timeout))
+	}
+	if _, err := c.Write([]byte("STATUS")); err != nil {
+		return KindUnknown, err
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := c.Read(buf)
+		if err != nil {
+			return KindUnknown, err
+		}
+		rsp := string(buf[:n])
+		if strings.HasPrefix(rsp, "<") {
+			// an event, not our response
+			continue
+		}
+		return classify(rsp)
+	}
+}
+
+func classify(rsp string) (Kind, error) {
+	switch {
+	case strings.Contains(rsp, "wpa_state="):
+		return KindSupplicant, nil
+	case hasKey(rsp, "state"):
+		return KindHostapd, nil
+	case hasKey(rsp, "ifname"), hasKey(rsp, "p2p_device_address"),
+		strings.HasPrefix(rsp, "UNKNOWN COMMAND"):
+		return KindGlobal, nil
+	}
+	return KindUnknown, errors.New("unrecognized STATUS response")
+}
+
+// hasKey reports whether a key=value response has a line for key.
+func hasKey(rsp, key string) bool {
+	return strings.HasPrefix(rsp, key+"=") || strings.Contains(rsp, "\n"+key+"=")
+}
+
+type WatchOp int
+
+const (
+	SocketAdded WatchOp = iota
+	SocketRemoved
+)
+
+// WatchEvent reports a control socket appearing or disappearing.
+// For removed sockets, Kind is what the socket was probed as when it was seen.
+type WatchEvent struct {
+	Op     WatchOp
+	Socket Socket
+}
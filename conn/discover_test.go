@@ -0,0 +1,110 @@
+package conn
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+// serve answers every datagram on the listener with rsp.
+func serve(t *testing.T, dir, name, rsp string) ListenConn {
+	lc, err := NewUnixListen(dir, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			_, addr, err := lc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			c, err := lc.Get(addr)
+			if err != nil {
+				return
+			}
+			c.Write([]byte(rsp))
+			c.Close()
+		}
+	}()
+	return lc
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "wpactrl-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	defer serve(t, dir, "wlan0", "bssid=00:1a:dd:18:a4:25\nwpa_state=COMPLETED\n").Close()
+	defer serve(t, dir, "ap0", "state=ENABLED\nphy=phy0\n").Close()
+	defer serve(t, dir, "global", "p2p_device_address=02:00:00:00:00:01\np2p_state=IDLE\nifname=wlan0\naddress=02:00:00:00:00:01\n").Close()
+	defer serve(t, dir, "hostapd-global", "UNKNOWN COMMAND\n").Close()
+	if err := ioutil.WriteFile(path.Join(dir, "notasocket"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sockets, err := Discover(dir, path.Join(dir, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]Kind)
+	for _, s := range sockets {
+		kinds[s.Name] = s.Kind
+	}
+	if len(kinds) != 4 || kinds["wlan0"] != KindSupplicant || kinds["ap0"] != KindHostapd ||
+		kinds["global"] != KindGlobal || kinds["hostapd-global"] != KindGlobal {
+		t.Fatal("wrong sockets", sockets)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	defer serve(t, dir, "wlan0", "wpa_state=SCANNING\n").Close()
+
+	w, err := (&Discovery{Dirs: []string{dir}}).Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	next := func() WatchEvent {
+		select {
+		case evt := <-w.Events():
+			return evt
+		case <-time.After(2 * time.Second):
+			t.Fatal("no watch event")
+		}
+		return WatchEvent{}
+	}
+
+	if evt := next(); evt.Op != SocketAdded || evt.Socket.Name != "wlan0" || evt.Socket.Kind != KindSupplicant {
+		t.Fatal("wrong existing event", evt)
+	}
+
+	ap := serve(t, dir, "ap0", "state=ENABLED\n")
+	if evt := next(); evt.Op != SocketAdded || evt.Socket.Name != "ap0" || evt.Socket.Kind != KindHostapd {
+		t.Fatal("wrong added event", evt)
+	}
+
+	ap.Close()
+	os.Remove(path.Join(dir, "ap0"))
+	if evt := next(); evt.Op != SocketRemoved || evt.Socket.Name != "ap0" || evt.Socket.Kind != KindHostapd {
+		t.Fatal("wrong removed event", evt)
+	}
+
+	w.Close()
+	if _, ok := <-w.Events(); ok {
+		t.Fatal("events not closed")
+	}
+}
@@ -0,0 +1,138 @@
+package conn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSupplicantUDPPort = 9877
+	DefaultHostapdUDPPort    = 8877
+)
+
+// udpConn talks to a control interface built with CONFIG_CTRL_IFACE_UDP.
+// Every command must be prefixed with a cookie obtained by GET_COOKIE. Commands
+// with a stale cookie are silently dropped by the server, so callers that see
+// a timeout should call RefreshCookie, and retry only if the cookie changed.
+type udpConn struct {
+	*net.UDPConn
+	timeout time.Duration
+
+	mu     sync.Mutex
+	cookie string
+	// cookies receives cookies picked out of the read stream by Read
+	cookies chan string
+}
+
+// NewUDPConn connects to the control interface at addr (host:port) and fetches
+// the initial cookie. timeout bounds the wait for cookie responses.
+func NewUDPConn(addr string, timeout time.Duration) (Conn, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return nil, err
+	}
+	uc := &udpConn{
+		UDPConn: c,
+		timeout: timeout,
+		cookies: make(chan string, 1),
+	}
+
+	// Nothing else is reading yet, so read the first cookie directly.
+	if _, err := c.Write([]byte("GET_COOKIE")); err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, 256)
+	n, err := c.Read(buf)
+	c.SetReadDeadline(time.Time{})
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("get cookie: %v", err)
+	}
+	cookie, ok := parseCookie(string(buf[:n]))
+	if !ok {
+		c.Close()
+		return nil, fmt.Errorf("bad cookie response %q", buf[:n])
+	}
+	uc.cookie = cookie
+	return uc, nil
+}
+
+func parseCookie(rsp string) (string, bool) {
+	rsp = strings.TrimSpace(rsp)
+	if !strings.HasPrefix(rsp, "COOKIE=") {
+		return "", false
+	}
+	return rsp, true
+}
+
+// Write sends b prefixed with the current cookie. The returned count doesn't
+// include the prefix.
+func (uc *udpConn) Write(b []byte) (int, error) {
+	uc.mu.Lock()
+	cookie := uc.cookie
+	uc.mu.Unlock()
+
+	msg := make([]byte, 0, len(cookie)+1+len(b))
+	msg = append(msg, cookie...)
+	msg = append(msg, ' ')
+	msg = append(msg, b...)
+	if _, err := uc.UDPConn.Write(msg); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Read returns the next response or event. Cookie responses are consumed here
+// and handed to RefreshCookie.
+func (uc *udpConn) Read(b []byte) (int, error) {
+	for {
+		n, err := uc.UDPConn.Read(b)
+		if err != nil {
+			return n, err
+		}
+		if cookie, ok := parseCookie(string(b[:n])); ok {
+			uc.mu.Lock()
+			uc.cookie = cookie
+			uc.mu.Unlock()
+			select {
+			case uc.cookies <- cookie:
+			default:
+			}
+			continue
+		}
+		return n, nil
+	}
+}
+
+// RefreshCookie fetches a new cookie, for when the server has restarted or
+// rotated it, and reports whether it differs from the one in use. It relies on
+// another goroutine calling Read, as WPACtrl does.
+func (uc *udpConn) RefreshCookie() (bool, error) {
+	// drop any cookie that arrived late from an earlier refresh
+	select {
+	case <-uc.cookies:
+	default:
+	}
+	uc.mu.Lock()
+	old := uc.cookie
+	uc.mu.Unlock()
+	if _, err := uc.UDPConn.Write([]byte("GET_COOKIE")); err != nil {
+		return false, err
+	}
+	select {
+	case cookie := <-uc.cookies:
+		return cookie != old, nil
+	case <-time.After(uc.timeout):
+		return false, errors.New("cookie refresh timeout")
+	}
+}
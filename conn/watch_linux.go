@@ -0,0 +1,152 @@
+package conn
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Watcher reports control sockets appearing and disappearing, using inotify.
+type Watcher struct {
+	d      *Discovery
+	f      *os.File
+	dirs   map[int32]string
+	known  map[string]Socket
+	events chan WatchEvent
+	done   chan struct{}
+
+	closeOnce sync.Once
+}
+
+// Watch starts watching the discovery directories. Sockets that already exist
+// are reported as added before any changes.
+func (d *Discovery) Watch() (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		d:      d,
+		f:      os.NewFile(uintptr(fd), "inotify"),
+		dirs:   make(map[int32]string),
+		known:  make(map[string]Socket),
+		events: make(chan WatchEvent, 16),
+		done:   make(chan struct{}),
+	}
+
+	var dirs []string
+	for _, dir := range d.dirs() {
+		wd, err := syscall.InotifyAddWatch(fd, dir,
+			syscall.IN_CREATE|syscall.IN_DELETE|syscall.IN_MOVED_FROM|syscall.IN_MOVED_TO)
+		if err != nil {
+			continue
+		}
+		w.dirs[int32(wd)] = dir
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) == 0 {
+		w.f.Close()
+		return nil, errors.New("no directories to watch")
+	}
+
+	existing, err := (&Discovery{Dirs: dirs, ProbeTimeout: d.ProbeTimeout}).Discover()
+	if err != nil {
+		w.f.Close()
+		return nil, err
+	}
+
+	go w.run(existing)
+	return w, nil
+}
+
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Close stops watching and closes the Events channel. Only the first call has
+// any effect.
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.f.Close()
+	})
+	return err
+}
+
+func (w *Watcher) send(evt WatchEvent) bool {
+	select {
+	case w.events <- evt:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *Watcher) run(existing []Socket) {
+	defer close(w.events)
+
+	for _, s := range existing {
+		w.known[s.Path()] = s
+		if !w.send(WatchEvent{Op: SocketAdded, Socket: s}) {
+			return
+		}
+	}
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.f.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + syscall.SizeofInotifyEvent
+			off = start + int(ev.Len)
+			if off > n {
+				break
+			}
+			name := strings.TrimRight(string(buf[start:off]), "\x00")
+			dir, ok := w.dirs[ev.Wd]
+			if !ok || name == "" {
+				continue
+			}
+			if !w.handle(ev.Mask, dir, name) {
+				return
+			}
+		}
+	}
+}
+
+func (w *Watcher) handle(mask uint32, dir, name string) bool {
+	p := path.Join(dir, name)
+	if mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0 {
+		s, ok := w.known[p]
+		if !ok {
+			return true
+		}
+		delete(w.known, p)
+		return w.send(WatchEvent{Op: SocketRemoved, Socket: s})
+	}
+
+	// Sockets created between adding the watches and the initial Discover
+	// were already reported.
+	if _, ok := w.known[p]; ok {
+		return true
+	}
+	fi, err := os.Lstat(p)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return true
+	}
+	kind, err := Probe(dir, name, w.d.probeTimeout())
+	if err != nil {
+		kind = KindUnknown
+	}
+	s := Socket{Dir: dir, Name: name, Kind: kind}
+	w.known[p] = s
+	return w.send(WatchEvent{Op: SocketAdded, Socket: s})
+}
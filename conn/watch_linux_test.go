@@ -0,0 +1,31 @@
+package conn
+
+import (
+	"os"
+	"path"
+	"syscall"
+	"testing"
+)
+
+func TestWatchCreateKnown(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	defer serve(t, dir, "wlan0", "wpa_state=SCANNING\n").Close()
+
+	// The socket was found by the initial Discover, and its create event is
+	// still queued.
+	s := Socket{Dir: dir, Name: "wlan0", Kind: KindSupplicant}
+	w := &Watcher{
+		d:      &Discovery{Dirs: []string{dir}},
+		known:  map[string]Socket{path.Join(dir, "wlan0"): s},
+		events: make(chan WatchEvent, 16),
+		done:   make(chan struct{}),
+	}
+	if !w.handle(syscall.IN_CREATE, dir, "wlan0") {
+		t.Fatal("handle stopped")
+	}
+	if len(w.events) != 0 {
+		t.Fatal("known socket reported again", <-w.events)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+// +build !linux
+
+package conn
+
+import "errors"
+
+// Watcher reports control sockets appearing and disappearing.
+// Watching is only supported on Linux.
+type Watcher struct{}
+
+func (d *Discovery) Watch() (*Watcher, error) {
+	return nil, errors.New("watching is not supported on this platform")
+}
+
+func (w *Watcher) Events() <-chan WatchEvent {
+	return nil
+}
+
+func (w *Watcher) Close() error {
+	return nil
+}
@@ -0,0 +1,91 @@
+package wpa
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Credential fields wpa_supplicant asks for with CTRL-REQ-.
+const (
+	CredIdentity      = "IDENTITY"
+	CredPassword      = "PASSWORD"
+	CredNewPassword   = "NEW_PASSWORD"
+	CredPIN           = "PIN"
+	CredOTP           = "OTP"
+	CredPassphrase    = "PASSPHRASE"
+	CredSIM           = "SIM"
+	CredPSKPassphrase = "PSK_PASSPHRASE"
+	CredExtCertCheck  = "EXT_CERT_CHECK"
+)
+
+// OnCredentialRequestEvent is CTRL-REQ-<field>-<id>:<text>, sent when network
+// id needs a credential that isn't in its configuration. wpa_supplicant waits
+// for the answer, given with Respond.
+type OnCredentialRequestEvent struct {
+	baseEvent
+	Field   string
+	Network int
+	// Text is the human readable prompt, e.g. "Password needed for SSID corp".
+	Text string
+}
+
+func NewOnCredentialRequestEvent(msg string) *OnCredentialRequestEvent {
+	evt := &OnCredentialRequestEvent{baseEvent: baseEvent{msg}, Network: -1}
+	req := strings.TrimPrefix(msg, "CTRL-REQ-")
+	colon := strings.IndexByte(req, ':')
+	if colon < 0 {
+		return evt
+	}
+	evt.Text = req[colon+1:]
+	req = req[:colon]
+	if dash := strings.LastIndexByte(req, '-'); dash > 0 {
+		evt.Field = req[:dash]
+		evt.Network = atoi(req[dash+1:])
+	}
+	return evt
+}
+
+// Respond answers a credential request.
+func (c *WPASupplicantCtrl) Respond(req *OnCredentialRequestEvent, value string) error {
+	if req.Field == "" || req.Network < 0 {
+		return fmt.Errorf("malformed request %q", req.WPAString())
+	}
+	if strings.ContainsAny(value, "\n\r") {
+		return fmt.Errorf("%s contains a newline", req.Field)
+	}
+	return c.ctrl.OkCommand(fmt.Sprintf("CTRL-RSP-%s-%d:%s", req.Field, req.Network, value))
+}
+
+// CredentialProvider supplies the value for a credential request, typically by
+// prompting the user. If it returns an error the request is left unanswered.
+type CredentialProvider func(req *OnCredentialRequestEvent) (string, error)
+
+// SetCredentialProvider answers every credential request with p. Each request
+// is handled in its own goroutine, so p may block while the user is prompted.
+// The requests are still delivered on Events(). If answering a request fails,
+// onError, if not nil, is called with the request and the error. A nil p stops
+// answering.
+func (c *WPASupplicantCtrl) SetCredentialProvider(p CredentialProvider, onError func(req *OnCredentialRequestEvent, err error)) {
+	c.mu.Lock()
+	c.credProvider = p
+	c.credError = onError
+	c.mu.Unlock()
+}
+
+func (c *WPASupplicantCtrl) provideCredential(req *OnCredentialRequestEvent) {
+	c.mu.Lock()
+	p, onError := c.credProvider, c.credError
+	c.mu.Unlock()
+	if p == nil {
+		return
+	}
+	go func() {
+		value, err := p(req)
+		if err != nil {
+			return
+		}
+		if err := c.Respond(req, value); err != nil && onError != nil {
+			onError(req, err)
+		}
+	}()
+}
@@ -0,0 +1,98 @@
+package wpa
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCredentialRequestEvent(t *testing.T) {
+	evt, ok := parseSupplicantEvent("CTRL-REQ-PSK_PASSPHRASE-3:PSK or passphrase needed for SSID home-net").(*OnCredentialRequestEvent)
+	if !ok {
+		t.Fatal("expected credential request")
+	}
+	if evt.Field != CredPSKPassphrase || evt.Network != 3 || evt.Text != "PSK or passphrase needed for SSID home-net" {
+		t.Fatalf("wrong event %+v", evt)
+	}
+
+	_, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Respond(NewOnCredentialRequestEvent("CTRL-REQ-garbage"), "x"); err == nil {
+		t.Fatal("expected error for malformed request")
+	}
+}
+
+func TestCredentialProvider(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if _, err := ctrl.AddNetwork(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := make(chan string, 1)
+	mock.OnCtrlResponse = func(field string, id int, value string) {
+		responses <- field + ":" + value
+	}
+	ctrl.SetCredentialProvider(func(req *OnCredentialRequestEvent) (string, error) {
+		if req.Field == CredOTP {
+			return "", errors.New("no token")
+		}
+		return "hunter2", nil
+	}, nil)
+
+	mock.SendUnsol("<2>CTRL-REQ-OTP-0:OTP needed for SSID corp")
+	mock.SendUnsol("<2>CTRL-REQ-PASSWORD-0:Password needed for SSID corp")
+	for i := 0; i < 2; i++ {
+		select {
+		case evt := <-ctrl.Events():
+			if _, ok := evt.(*OnCredentialRequestEvent); !ok {
+				t.Fatalf("wrong event %+v", evt)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+		}
+	}
+
+	select {
+	case rsp := <-responses:
+		if rsp != "PASSWORD:hunter2" {
+			t.Fatal("wrong response", rsp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no response")
+	}
+	select {
+	case rsp := <-responses:
+		t.Fatal("unexpected response", rsp)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestCredentialProviderError(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, 1)
+	ctrl.SetCredentialProvider(func(req *OnCredentialRequestEvent) (string, error) {
+		return "hunter2", nil
+	}, func(req *OnCredentialRequestEvent, err error) {
+		if req.Field != CredPassword {
+			t.Errorf("wrong request %+v", req)
+		}
+		errs <- err
+	})
+
+	mock.Expect("CTRL-RSP-PASSWORD-0:hunter2", "FAIL")
+	mock.SendUnsol("<2>CTRL-REQ-PASSWORD-0:Password needed for SSID corp")
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no error reported")
+	}
+}
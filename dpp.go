@@ -0,0 +1,300 @@
+package wpa
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DPPCtrl runs Device Provisioning Protocol (Wi-Fi Easy Connect) commands.
+// wpa_supplicant and hostapd share the same DPP commands; get one from
+// WPASupplicantCtrl.DPP or HostapdCtrl.DPP.
+type DPPCtrl struct {
+	ctrl Ctrl
+}
+
+func (c *WPASupplicantCtrl) DPP() *DPPCtrl {
+	return &DPPCtrl{ctrl: c.ctrl}
+}
+
+func (c *HostapdCtrl) DPP() *DPPCtrl {
+	return &DPPCtrl{ctrl: c.ctrl}
+}
+
+// idCommand runs a command that returns a bootstrap or configurator id.
+func (d *DPPCtrl) idCommand(cmd string) (int, error) {
+	rsp, err := d.ctrl.FailCommand(cmd)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(rsp))
+	if err != nil {
+		return 0, fmt.Errorf("bad id %q", rsp)
+	}
+	return id, nil
+}
+
+// DPPBootstrapParams are the DPP_BOOTSTRAP_GEN parameters for a QR code.
+// Empty fields are omitted.
+type DPPBootstrapParams struct {
+	// Chan lists the channels to listen on as class/channel, e.g. "81/1".
+	Chan  []string
+	MAC   MAC
+	Info  string
+	Curve string
+	// Key is a hex encoded DER private key, to reuse a bootstrap key.
+	Key string
+}
+
+// BootstrapGen generates QR code bootstrapping information and returns its id.
+func (d *DPPCtrl) BootstrapGen(p DPPBootstrapParams) (int, error) {
+	args := []string{"DPP_BOOTSTRAP_GEN", "type=qrcode"}
+	if len(p.Chan) > 0 {
+		args = append(args, "chan="+strings.Join(p.Chan, ","))
+	}
+	if !p.MAC.IsZero() {
+		args = append(args, "mac="+p.MAC.String())
+	}
+	if p.Info != "" {
+		if strings.ContainsAny(p.Info, " ;") {
+			return 0, errors.New("info can't contain spaces or semicolons")
+		}
+		args = append(args, "info="+p.Info)
+	}
+	if p.Curve != "" {
+		args = append(args, "curve="+p.Curve)
+	}
+	if p.Key != "" {
+		args = append(args, "key="+p.Key)
+	}
+	return d.idCommand(strings.Join(args, " "))
+}
+
+// BootstrapGetURI returns the DPP: URI to show as a QR code.
+func (d *DPPCtrl) BootstrapGetURI(id int) (string, error) {
+	return d.ctrl.FailCommand(fmt.Sprintf("DPP_BOOTSTRAP_GET_URI %d", id))
+}
+
+// DPPBootstrapInfo is the response to DPP_BOOTSTRAP_INFO.
+type DPPBootstrapInfo struct {
+	// Type is QRCODE, PKEX or NFC-URI.
+	Type    string
+	MACAddr MAC
+	Info    string
+	NumFreq int
+	UseFreq int
+	Curve   string
+	PKHash  string
+	Raw     map[string]string
+}
+
+func (d *DPPCtrl) BootstrapInfo(id int) (*DPPBootstrapInfo, error) {
+	rsp, err := d.ctrl.FailCommand(fmt.Sprintf("DPP_BOOTSTRAP_INFO %d", id))
+	if err != nil {
+		return nil, err
+	}
+	kv := parseKeyValues(rsp)
+	mac, _ := ParseMAC(kv["mac_addr"])
+	return &DPPBootstrapInfo{
+		Type:    kv["type"],
+		MACAddr: mac,
+		Info:    kv["info"],
+		NumFreq: atoi(kv["num_freq"]),
+		UseFreq: atoi(kv["use_freq"]),
+		Curve:   kv["curve"],
+		PKHash:  kv["pkhash"],
+		Raw:     kv,
+	}, nil
+}
+
+// QRCode parses a peer's scanned DPP: URI and returns its bootstrap id, for
+// DPPAuthParams.Peer.
+func (d *DPPCtrl) QRCode(uri string) (int, error) {
+	if !strings.HasPrefix(uri, "DPP:") {
+		return 0, errors.New("not a DPP URI")
+	}
+	return d.idCommand("DPP_QR_CODE " + uri)
+}
+
+// Listen waits for DPP authentication on freq, in MHz. role is "configurator",
+// "enrollee" or empty for either.
+func (d *DPPCtrl) Listen(freq int, role string) error {
+	cmd := fmt.Sprintf("DPP_LISTEN %d", freq)
+	if role != "" {
+		cmd += " role=" + role
+	}
+	return d.ctrl.OkCommand(cmd)
+}
+
+func (d *DPPCtrl) StopListen() error {
+	return d.ctrl.OkCommand("DPP_STOP_LISTEN")
+}
+
+// DPPConfParams describe the configuration object a configurator provisions.
+type DPPConfParams struct {
+	// Conf is the configuration type, e.g. sta-dpp, sta-psk, sta-sae, ap-dpp.
+	Conf         string
+	Configurator int
+	SSID         string
+	// Pass is the passphrase for the psk and sae configuration types.
+	Pass string
+}
+
+func (p DPPConfParams) args() []string {
+	var args []string
+	if p.Conf != "" {
+		args = append(args, "conf="+p.Conf)
+	}
+	if p.Configurator != 0 {
+		args = append(args, fmt.Sprintf("configurator=%d", p.Configurator))
+	}
+	if p.SSID != "" {
+		args = append(args, "ssid="+hex.EncodeToString([]byte(p.SSID)))
+	}
+	if p.Pass != "" {
+		args = append(args, "pass="+hex.EncodeToString([]byte(p.Pass)))
+	}
+	return args
+}
+
+// DPPAuthParams are the DPP_AUTH_INIT parameters.
+type DPPAuthParams struct {
+	// Peer is the bootstrap id from QRCode.
+	Peer int
+	// Own is this device's bootstrap id for mutual authentication, or 0.
+	Own int
+	// Role is "configurator" or "enrollee"; empty defaults to configurator.
+	Role string
+	// Conf is the configuration to provision when acting as configurator.
+	Conf    DPPConfParams
+	NegFreq int
+}
+
+// AuthInit starts DPP authentication with the peer. Progress is reported
+// with DPP-AUTH-SUCCESS and, on the enrollee, DPP-CONF-RECEIVED and the
+// DPP-CONFOBJ-* events.
+func (d *DPPCtrl) AuthInit(p DPPAuthParams) error {
+	if p.Peer == 0 {
+		return errors.New("peer bootstrap id is required")
+	}
+	args := []string{"DPP_AUTH_INIT", fmt.Sprintf("peer=%d", p.Peer)}
+	if p.Own != 0 {
+		args = append(args, fmt.Sprintf("own=%d", p.Own))
+	}
+	if p.Role != "" {
+		args = append(args, "role="+p.Role)
+	}
+	args = append(args, p.Conf.args()...)
+	if p.NegFreq != 0 {
+		args = append(args, fmt.Sprintf("neg_freq=%d", p.NegFreq))
+	}
+	return d.ctrl.OkCommand(strings.Join(args, " "))
+}
+
+// ConfiguratorAdd creates a configurator and returns its id. An empty curve
+// uses the default, prime256v1.
+func (d *DPPCtrl) ConfiguratorAdd(curve string) (int, error) {
+	cmd := "DPP_CONFIGURATOR_ADD"
+	if curve != "" {
+		cmd += " curve=" + curve
+	}
+	return d.idCommand(cmd)
+}
+
+// ConfiguratorSign has configurator p.Configurator provision this device
+// itself, reported with the DPP-CONFOBJ-* events.
+func (d *DPPCtrl) ConfiguratorSign(p DPPConfParams) error {
+	if p.Configurator == 0 || p.Conf == "" {
+		return errors.New("conf and configurator are required")
+	}
+	return d.ctrl.OkCommand(strings.Join(append([]string{"DPP_CONFIGURATOR_SIGN"}, p.args()...), " "))
+}
+
+// OnDPPRxEvent is DPP-RX, sent for each DPP frame received. Type is the DPP
+// public action frame type.
+type OnDPPRxEvent struct {
+	baseEvent
+	Src  MAC
+	Freq int
+	Type int
+}
+
+func NewOnDPPRxEvent(msg string) *OnDPPRxEvent {
+	f := parseEventFields(msg)
+	src, _ := ParseMAC(f["src"])
+	return &OnDPPRxEvent{
+		baseEvent: baseEvent{msg},
+		Src:       src,
+		Freq:      atoi(f["freq"]),
+		Type:      atoi(f["type"]),
+	}
+}
+
+// OnDPPAuthSuccessEvent is DPP-AUTH-SUCCESS. Initiator is set when this device
+// started authentication.
+type OnDPPAuthSuccessEvent struct {
+	baseEvent
+	Initiator bool
+}
+
+type OnDPPConfReceivedEvent struct{ baseEvent }
+type OnDPPConfFailedEvent struct{ baseEvent }
+
+// OnDPPConfObjEvent is one of DPP-CONFOBJ-AKM, -SSID, -PASS and -PSK, the parts
+// of a received configuration object.
+type OnDPPConfObjEvent struct {
+	baseEvent
+	// Type is the part of the event name after DPP-CONFOBJ-.
+	Type string
+	// Value is decoded for PASS, which wpa_supplicant sends hex encoded.
+	Value string
+}
+
+func NewOnDPPConfObjEvent(msg string) *OnDPPConfObjEvent {
+	name, value := msg, ""
+	if sp := strings.IndexByte(msg, ' '); sp >= 0 {
+		name, value = msg[:sp], msg[sp+1:]
+	}
+	evt := &OnDPPConfObjEvent{
+		baseEvent: baseEvent{msg},
+		Type:      strings.TrimPrefix(name, "DPP-CONFOBJ-"),
+		Value:     value,
+	}
+	if evt.Type == "PASS" {
+		if b, err := hex.DecodeString(value); err == nil {
+			evt.Value = string(b)
+		}
+	}
+	return evt
+}
+
+// OnDPPFailEvent is DPP-FAIL; Msg is the reason.
+type OnDPPFailEvent struct {
+	baseEvent
+	Msg string
+}
+
+// parseDPPEvent parses the DPP events shared by wpa_supplicant and hostapd.
+// It returns nil for anything else.
+func parseDPPEvent(msg string) WPASupplicantEvent {
+	if !strings.HasPrefix(msg, "DPP-") {
+		return nil
+	}
+	switch {
+	case strings.HasPrefix(msg, "DPP-RX"):
+		return NewOnDPPRxEvent(msg)
+	case strings.HasPrefix(msg, "DPP-AUTH-SUCCESS"):
+		return &OnDPPAuthSuccessEvent{baseEvent: baseEvent{msg}, Initiator: parseEventFields(msg)["init"] == "1"}
+	case strings.HasPrefix(msg, "DPP-CONF-RECEIVED"):
+		return &OnDPPConfReceivedEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "DPP-CONF-FAILED"):
+		return &OnDPPConfFailedEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "DPP-CONFOBJ-"):
+		return NewOnDPPConfObjEvent(msg)
+	case strings.HasPrefix(msg, "DPP-FAIL"):
+		return &OnDPPFailEvent{baseEvent: baseEvent{msg}, Msg: strings.TrimSpace(strings.TrimPrefix(msg, "DPP-FAIL"))}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package wpa
+
+import (
+	"testing"
+
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+func TestDPPEnrollee(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	dpp := ctrl.DPP()
+
+	id, err := dpp.BootstrapGen(DPPBootstrapParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1 {
+		t.Fatal("wrong id", id)
+	}
+	mock.Expect("DPP_BOOTSTRAP_GEN type=qrcode chan=81/1,115/36 mac=02:00:00:00:01:00 info=sensor-7", "5")
+	_, err = dpp.BootstrapGen(DPPBootstrapParams{
+		Chan: []string{"81/1", "115/36"},
+		MAC:  MustParseMAC("02:00:00:00:01:00"),
+		Info: "sensor-7",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dpp.BootstrapGen(DPPBootstrapParams{Info: "a;b"}); err == nil {
+		t.Fatal("expected info error")
+	}
+
+	uri, err := dpp.BootstrapGetURI(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri != wpatest.DPPURI {
+		t.Fatal("wrong uri", uri)
+	}
+	info, err := dpp.BootstrapInfo(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Type != "QRCODE" || info.MACAddr != MustParseMAC("02:00:00:00:01:00") || info.UseFreq != 2412 || info.Curve != "prime256v1" {
+		t.Fatalf("wrong info %+v", info)
+	}
+	if _, err := dpp.BootstrapInfo(7); err == nil {
+		t.Fatal("expected unknown id to fail")
+	}
+
+	mock.Expect("DPP_LISTEN 2412 role=enrollee", "OK")
+	if err := dpp.Listen(2412, "enrollee"); err != nil {
+		t.Fatal(err)
+	}
+	if err := dpp.StopListen(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDPPConfigurator(t *testing.T) {
+	mock, ap := NewHostapdTest(t)
+	dpp := ap.DPP()
+
+	conf, err := dpp.ConfiguratorAdd("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := dpp.QRCode(wpatest.DPPURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dpp.QRCode("WIFI:S:home;;"); err == nil {
+		t.Fatal("expected non-DPP URI to fail")
+	}
+
+	mock.Expect("DPP_AUTH_INIT peer=1 role=configurator conf=sta-psk configurator=1 ssid=686f6d65 pass=73656372657421", "OK")
+	err = dpp.AuthInit(DPPAuthParams{
+		Peer: peer,
+		Role: "configurator",
+		Conf: DPPConfParams{Conf: "sta-psk", Configurator: conf, SSID: "home", Pass: "secret!"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dpp.AuthInit(DPPAuthParams{}); err == nil {
+		t.Fatal("expected missing peer error")
+	}
+}
+
+func TestDPPSelfConfigure(t *testing.T) {
+	_, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+	dpp := ctrl.DPP()
+
+	conf, err := dpp.ConfiguratorAdd("prime256v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dpp.ConfiguratorSign(DPPConfParams{Conf: "sta-dpp", Configurator: conf, SSID: "sensors"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := nextSupplicantEvent(t, ctrl).(*OnDPPConfReceivedEvent); !ok {
+		t.Fatal("expected conf received")
+	}
+	akm, ok := nextSupplicantEvent(t, ctrl).(*OnDPPConfObjEvent)
+	if !ok || akm.Type != "AKM" || akm.Value != "dpp" {
+		t.Fatalf("wrong event %+v", akm)
+	}
+	ssid, ok := nextSupplicantEvent(t, ctrl).(*OnDPPConfObjEvent)
+	if !ok || ssid.Type != "SSID" || ssid.Value != "sensors" {
+		t.Fatalf("wrong event %+v", ssid)
+	}
+	if err := dpp.ConfiguratorSign(DPPConfParams{SSID: "sensors"}); err == nil {
+		t.Fatal("expected missing configurator error")
+	}
+}
+
+func TestDPPEvents(t *testing.T) {
+	rx, ok := parseHostapdEvent("DPP-RX src=02:00:00:00:02:00 freq=2412 type=0").(*OnDPPRxEvent)
+	if !ok || rx.Src != MustParseMAC("02:00:00:00:02:00") || rx.Freq != 2412 || rx.Type != 0 {
+		t.Fatalf("wrong event %+v", rx)
+	}
+	auth, ok := parseSupplicantEvent("DPP-AUTH-SUCCESS init=1").(*OnDPPAuthSuccessEvent)
+	if !ok || !auth.Initiator {
+		t.Fatalf("wrong event %+v", auth)
+	}
+	pass, ok := parseSupplicantEvent("DPP-CONFOBJ-PASS 73656372657421").(*OnDPPConfObjEvent)
+	if !ok || pass.Type != "PASS" || pass.Value != "secret!" {
+		t.Fatalf("wrong event %+v", pass)
+	}
+	fail, ok := parseSupplicantEvent("DPP-FAIL Configurator rejected configuration").(*OnDPPFailEvent)
+	if !ok || fail.Msg != "Configurator rejected configuration" {
+		t.Fatalf("wrong event %+v", fail)
+	}
+	if _, ok := parseSupplicantEvent("DPP-CONF-FAILED").(*OnDPPConfFailedEvent); !ok {
+		t.Fatal("expected conf failed")
+	}
+	if _, ok := parseSupplicantEvent("DPP-TX dst=02:00:00:00:02:00 freq=2412 type=1").(*OnEvent); !ok {
+		t.Fatal("expected untyped event")
+	}
+}
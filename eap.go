@@ -0,0 +1,165 @@
+package wpa
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EAP methods for EAPConfig.Method.
+const (
+	EAPPEAP = "PEAP"
+	EAPTTLS = "TTLS"
+	EAPTLS  = "TLS"
+	EAPPWD  = "PWD"
+	EAPSIM  = "SIM"
+	EAPAKA  = "AKA"
+)
+
+// EAPConfig is the 802.1X part of a network block. Empty fields are not set.
+type EAPConfig struct {
+	Method            string
+	Identity          string
+	AnonymousIdentity string
+	// Password is either the plain password, or "hash:" followed by the 32 hex
+	// digit NtPasswordHash for MSCHAPv2 based methods.
+	Password string
+	// Phase1 and Phase2 are passed through, e.g. "peapver=0" and "auth=MSCHAPV2".
+	Phase1 string
+	Phase2 string
+	// CACert, ClientCert and PrivateKey are file paths or blob:// references.
+	CACert            string
+	ClientCert        string
+	PrivateKey        string
+	PrivateKeyPasswd  string
+	DomainSuffixMatch string
+	// AltSubjectMatch entries are of the form "DNS:server.example.com".
+	AltSubjectMatch []string
+}
+
+// Validate checks that the settings the method needs are present. Password
+// may be left empty, to be supplied when wpa_supplicant sends
+// CTRL-REQ-PASSWORD. For EAP-TLS, ClientCert may be empty when PrivateKey is a
+// PKCS#12 file holding both.
+func (e *EAPConfig) Validate() error {
+	switch e.Method {
+	case EAPPEAP, EAPTTLS, EAPPWD:
+		if e.Identity == "" {
+			return fmt.Errorf("EAP-%s requires identity", e.Method)
+		}
+	case EAPTLS:
+		if e.Identity == "" || e.PrivateKey == "" {
+			return errors.New("EAP-TLS requires identity and private_key")
+		}
+	case EAPSIM, EAPAKA:
+	case "":
+		return errors.New("EAP method is required")
+	default:
+		return fmt.Errorf("unknown EAP method %q", e.Method)
+	}
+	if strings.HasPrefix(e.Password, "hash:") {
+		h := strings.TrimPrefix(e.Password, "hash:")
+		if _, err := hex.DecodeString(h); err != nil || len(h) != 32 {
+			return errors.New("password hash must be 32 hex digits")
+		}
+	}
+	return nil
+}
+
+// SetEAP validates e and stores its settings in the config. key_mgmt is set to
+// WPA-EAP unless it was already set.
+func (n *NetworkConfig) SetEAP(e *EAPConfig) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	if _, ok := n.Get("key_mgmt"); !ok {
+		n.SetKeyMgmt("WPA-EAP")
+	}
+	n.Set("eap", e.Method)
+	setString := func(name, value string) {
+		if value != "" {
+			n.SetString(name, value)
+		}
+	}
+	setString("identity", e.Identity)
+	setString("anonymous_identity", e.AnonymousIdentity)
+	if strings.HasPrefix(e.Password, "hash:") {
+		n.Set("password", e.Password)
+	} else {
+		setString("password", e.Password)
+	}
+	setString("phase1", e.Phase1)
+	setString("phase2", e.Phase2)
+	setString("ca_cert", e.CACert)
+	setString("client_cert", e.ClientCert)
+	setString("private_key", e.PrivateKey)
+	setString("private_key_passwd", e.PrivateKeyPasswd)
+	setString("domain_suffix_match", e.DomainSuffixMatch)
+	setString("altsubject_match", strings.Join(e.AltSubjectMatch, ";"))
+	return nil
+}
+
+// ConfigureEAP applies an EAP configuration to network id.
+func (c *WPASupplicantCtrl) ConfigureEAP(network string, e *EAPConfig) error {
+	cfg := NewNetworkConfig()
+	if err := cfg.SetEAP(e); err != nil {
+		return err
+	}
+	return c.ConfigureNetwork(network, cfg)
+}
+
+type OnEAPStartedEvent struct{ baseEvent }
+type OnEAPSuccessEvent struct{ baseEvent }
+type OnEAPFailureEvent struct{ baseEvent }
+
+// OnEAPMethodEvent is CTRL-EVENT-EAP-METHOD, sent when the EAP method has been
+// negotiated.
+type OnEAPMethodEvent struct {
+	baseEvent
+	Vendor int
+	Method int
+	// Name is the method name, e.g. PEAP.
+	Name string
+}
+
+// NewOnEAPMethodEvent parses "CTRL-EVENT-EAP-METHOD EAP vendor 0 method 25 (PEAP) selected".
+func NewOnEAPMethodEvent(msg string) *OnEAPMethodEvent {
+	evt := &OnEAPMethodEvent{baseEvent: baseEvent{msg}}
+	f := strings.Fields(msg)
+	for i := 1; i < len(f); i++ {
+		switch {
+		case f[i] == "vendor" && i+1 < len(f):
+			evt.Vendor = atoi(f[i+1])
+		case f[i] == "method" && i+1 < len(f):
+			evt.Method = atoi(f[i+1])
+		case strings.HasPrefix(f[i], "(") && strings.HasSuffix(f[i], ")"):
+			evt.Name = f[i][1 : len(f[i])-1]
+		}
+	}
+	return evt
+}
+
+// OnEAPPeerCertEvent is CTRL-EVENT-EAP-PEER-CERT, sent for each certificate in
+// the server's chain. Depth 0 is the server certificate.
+type OnEAPPeerCertEvent struct {
+	baseEvent
+	Depth   int
+	Subject string
+	// Hash is the hex SHA256 of the certificate, when reported.
+	Hash string
+	// Cert is the DER certificate, only sent when cert_in_cb is enabled.
+	Cert []byte
+}
+
+func NewOnEAPPeerCertEvent(msg string) *OnEAPPeerCertEvent {
+	f := parseEventFields(msg)
+	cert, _ := hex.DecodeString(f["cert"])
+	return &OnEAPPeerCertEvent{
+		baseEvent: baseEvent{msg},
+		Depth:     atoi(f["depth"]),
+		Subject:   f["subject"],
+		Hash:      f["hash"],
+		Cert:      cert,
+	}
+}
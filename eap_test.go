@@ -0,0 +1,107 @@
+package wpa
+
+import "testing"
+
+func TestEAPConfig(t *testing.T) {
+	cfg := NewNetworkConfig()
+	err := cfg.SetEAP(&EAPConfig{
+		Method:            EAPPEAP,
+		Identity:          "alice",
+		AnonymousIdentity: "anonymous",
+		Password:          "hash:8846f7eaee8fb117ad06bdd830b7586c",
+		Phase2:            "auth=MSCHAPV2",
+		CACert:            "/etc/ssl/ca.pem",
+		DomainSuffixMatch: "example.com",
+		AltSubjectMatch:   []string{"DNS:radius.example.com", "DNS:radius2.example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []NetworkVar{
+		{"key_mgmt", "WPA-EAP"},
+		{"eap", "PEAP"},
+		{"identity", `"alice"`},
+		{"anonymous_identity", `"anonymous"`},
+		{"password", "hash:8846f7eaee8fb117ad06bdd830b7586c"},
+		{"phase2", `"auth=MSCHAPV2"`},
+		{"ca_cert", `"/etc/ssl/ca.pem"`},
+		{"domain_suffix_match", `"example.com"`},
+		{"altsubject_match", `"DNS:radius.example.com;DNS:radius2.example.com"`},
+	}
+	vars := cfg.Vars()
+	if len(vars) != len(expect) {
+		t.Fatalf("wrong vars %v", vars)
+	}
+	for i := range expect {
+		if vars[i] != expect[i] {
+			t.Fatalf("var %d: expected %v, got %v", i, expect[i], vars[i])
+		}
+	}
+
+	for _, bad := range []*EAPConfig{
+		{},
+		{Method: "LEAP"},
+		{Method: EAPTTLS, Password: "secret"},
+		{Method: EAPTLS, Identity: "alice", ClientCert: "/etc/ssl/client.pem"},
+		{Method: EAPPEAP, Identity: "alice", Password: "hash:1234"},
+	} {
+		if err := NewNetworkConfig().SetEAP(bad); err == nil {
+			t.Fatalf("expected error for %+v", bad)
+		}
+	}
+
+	for _, good := range []*EAPConfig{
+		// The password is requested with CTRL-REQ-PASSWORD.
+		{Method: EAPTTLS, Identity: "alice", Phase2: "auth=PAP"},
+		// A PKCS#12 private key includes the client certificate.
+		{Method: EAPTLS, Identity: "alice", PrivateKey: "/etc/ssl/alice.p12"},
+	} {
+		if err := NewNetworkConfig().SetEAP(good); err != nil {
+			t.Fatalf("unexpected error for %+v: %v", good, err)
+		}
+	}
+}
+
+func TestConfigureEAP(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+
+	mock.Expect("SET_NETWORK 0 key_mgmt WPA-EAP", "OK")
+	mock.Expect("SET_NETWORK 0 eap TLS", "OK")
+	mock.Expect(`SET_NETWORK 0 identity "alice@example.com"`, "OK")
+	mock.Expect(`SET_NETWORK 0 client_cert "blob://client"`, "OK")
+	mock.Expect(`SET_NETWORK 0 private_key "/etc/ssl/key.pem"`, "OK")
+	err := ctrl.ConfigureEAP("0", &EAPConfig{
+		Method:     EAPTLS,
+		Identity:   "alice@example.com",
+		ClientCert: "blob://client",
+		PrivateKey: "/etc/ssl/key.pem",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEAPEvents(t *testing.T) {
+	evt := parseSupplicantEvent("CTRL-EVENT-EAP-METHOD EAP vendor 0 method 25 (PEAP) selected")
+	method, ok := evt.(*OnEAPMethodEvent)
+	if !ok || method.Vendor != 0 || method.Method != 25 || method.Name != "PEAP" {
+		t.Fatalf("wrong event %+v", evt)
+	}
+
+	evt = parseSupplicantEvent("CTRL-EVENT-EAP-PEER-CERT depth=0 subject='/C=US/O=Example/CN=radius.example.com' hash=5a1bc1 cert=3082")
+	cert, ok := evt.(*OnEAPPeerCertEvent)
+	if !ok || cert.Depth != 0 || cert.Subject != "/C=US/O=Example/CN=radius.example.com" ||
+		cert.Hash != "5a1bc1" || len(cert.Cert) != 2 {
+		t.Fatalf("wrong event %+v", evt)
+	}
+
+	if _, ok := parseSupplicantEvent("CTRL-EVENT-EAP-STARTED EAP authentication started").(*OnEAPStartedEvent); !ok {
+		t.Fatal("expected started event")
+	}
+	if _, ok := parseSupplicantEvent("CTRL-EVENT-EAP-SUCCESS EAP authentication completed successfully").(*OnEAPSuccessEvent); !ok {
+		t.Fatal("expected success event")
+	}
+	if _, ok := parseSupplicantEvent("CTRL-EVENT-EAP-FAILURE EAP authentication failed").(*OnEAPFailureEvent); !ok {
+		t.Fatal("expected failure event")
+	}
+}
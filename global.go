@@ -0,0 +1,239 @@
+package wpa
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GlobalCtrl wraps a WPACtrl connected to wpa_supplicant's global control
+// interface (wpa_supplicant -g /var/run/wpa_supplicant-global).
+// It manages interfaces, and hands out per-interface WPASupplicantCtrls that
+// share the global connection by prefixing their commands with IFNAME=.
+type GlobalCtrl struct {
+	ctrl        Ctrl
+	cmdTimeout  time.Duration
+	unsolicited chan string
+
+	mu       sync.Mutex
+	ifaces   []*ifaceCtrl
+	attached int
+}
+
+func NewGlobalCtrl(ctrl Ctrl, cmdTimeout time.Duration) *GlobalCtrl {
+	g := &GlobalCtrl{
+		ctrl:        ctrl,
+		cmdTimeout:  cmdTimeout,
+		unsolicited: make(chan string, 100),
+	}
+	go g.routeLoop()
+	return g
+}
+
+// routeLoop hands events prefixed with IFNAME= to the matching interface ctrls.
+// Everything else is a global event. Sends never block, so an interface whose
+// events aren't being read drops them rather than stalling the others.
+func (g *GlobalCtrl) routeLoop() {
+	for msg := range g.ctrl.Unsolicited() {
+		g.mu.Lock()
+		if strings.HasPrefix(msg, "IFNAME=") {
+			ifname, rest := msg[len("IFNAME="):], ""
+			if sp := strings.IndexByte(ifname, ' '); sp >= 0 {
+				ifname, rest = ifname[:sp], ifname[sp+1:]
+			}
+			for _, ic := range g.ifaces {
+				if ic.ifname == ifname {
+					select {
+					case ic.unsolicited <- rest:
+					default:
+					}
+				}
+			}
+		} else {
+			select {
+			case g.unsolicited <- msg:
+			default:
+			}
+		}
+		g.mu.Unlock()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, ic := range g.ifaces {
+		close(ic.unsolicited)
+	}
+	g.ifaces = nil
+	close(g.unsolicited)
+}
+
+// Unsolicited returns events that aren't tied to an interface.
+func (g *GlobalCtrl) Unsolicited() <-chan string {
+	return g.unsolicited
+}
+
+func (g *GlobalCtrl) Ctrl() Ctrl {
+	return g.ctrl
+}
+
+func (g *GlobalCtrl) Close() {
+	g.ctrl.Close()
+}
+
+// Attach registers for events on the global interface. Attach and Detach are
+// reference counted, since the per-interface ctrls share the connection.
+func (g *GlobalCtrl) Attach() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.attached == 0 {
+		if err := g.ctrl.Attach(); err != nil {
+			return err
+		}
+	}
+	g.attached++
+	return nil
+}
+
+func (g *GlobalCtrl) Detach() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.attached == 0 {
+		return nil
+	}
+	g.attached--
+	if g.attached == 0 {
+		return g.ctrl.Detach()
+	}
+	return nil
+}
+
+// Interfaces lists the interfaces wpa_supplicant is managing.
+func (g *GlobalCtrl) Interfaces() ([]string, error) {
+	rsp, err := g.ctrl.FailCommand("INTERFACES")
+	if err != nil {
+		return nil, err
+	}
+	var ifaces []string
+	for _, line := range strings.Split(rsp, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			ifaces = append(ifaces, line)
+		}
+	}
+	return ifaces, nil
+}
+
+// InterfaceParams are the INTERFACE_ADD parameters. Only Ifname is required.
+type InterfaceParams struct {
+	Ifname        string
+	ConfigFile    string
+	Driver        string
+	CtrlInterface string
+	DriverParam   string
+	Bridge        string
+}
+
+// InterfaceAdd asks wpa_supplicant to start managing an interface.
+func (g *GlobalCtrl) InterfaceAdd(p InterfaceParams) error {
+	if p.Ifname == "" {
+		return fmt.Errorf("missing ifname")
+	}
+	args := strings.Join([]string{
+		p.Ifname,
+		p.ConfigFile,
+		p.Driver,
+		p.CtrlInterface,
+		p.DriverParam,
+		p.Bridge,
+	}, "\t")
+	return g.ctrl.OkCommand("INTERFACE_ADD " + strings.TrimRight(args, "\t"))
+}
+
+// InterfaceRemove asks wpa_supplicant to stop managing an interface.
+func (g *GlobalCtrl) InterfaceRemove(ifname string) error {
+	return g.ctrl.OkCommand(fmt.Sprintf("INTERFACE_REMOVE %s", ifname))
+}
+
+// Interface returns a WPASupplicantCtrl for ifname that sends its commands
+// through the global interface. Closing it doesn't close the global connection.
+func (g *GlobalCtrl) Interface(ifname string) *WPASupplicantCtrl {
+	ic := &ifaceCtrl{
+		g:           g,
+		ifname:      ifname,
+		unsolicited: make(chan string, 100),
+	}
+	g.mu.Lock()
+	g.ifaces = append(g.ifaces, ic)
+	g.mu.Unlock()
+	return NewWPASupplicantCtrl(ic, g.cmdTimeout)
+}
+
+func (g *GlobalCtrl) release(ic *ifaceCtrl) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, c := range g.ifaces {
+		if c == ic {
+			g.ifaces = append(g.ifaces[:i], g.ifaces[i+1:]...)
+			close(ic.unsolicited)
+			return
+		}
+	}
+}
+
+// ifaceCtrl is a Ctrl for a single interface on the global control interface.
+type ifaceCtrl struct {
+	g           *GlobalCtrl
+	ifname      string
+	unsolicited chan string
+
+	mu       sync.Mutex
+	attached bool
+}
+
+func (ic *ifaceCtrl) prefix(cmd string) string {
+	return fmt.Sprintf("IFNAME=%s %s", ic.ifname, cmd)
+}
+
+func (ic *ifaceCtrl) Command(cmd string) (string, error) {
+	return ic.g.ctrl.Command(ic.prefix(cmd))
+}
+
+func (ic *ifaceCtrl) OkCommand(cmd string) error {
+	return ic.g.ctrl.OkCommand(ic.prefix(cmd))
+}
+
+func (ic *ifaceCtrl) FailCommand(cmd string) (string, error) {
+	return ic.g.ctrl.FailCommand(ic.prefix(cmd))
+}
+
+func (ic *ifaceCtrl) Attach() error {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	if ic.attached {
+		return nil
+	}
+	if err := ic.g.Attach(); err != nil {
+		return err
+	}
+	ic.attached = true
+	return nil
+}
+
+func (ic *ifaceCtrl) Detach() error {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	if !ic.attached {
+		return nil
+	}
+	ic.attached = false
+	return ic.g.Detach()
+}
+
+func (ic *ifaceCtrl) Close() {
+	ic.Detach()
+	ic.g.release(ic)
+}
+
+func (ic *ifaceCtrl) Unsolicited() <-chan string {
+	return ic.unsolicited
+}
@@ -0,0 +1,87 @@
+package wpa
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+func NewGlobalTest(t *testing.T) (*wpatest.WPAProcessMock, *GlobalCtrl) {
+	lc, c := NewTempConn(t)
+	mock := wpatest.NewWPAProcessMock(t, lc)
+
+	return mock, NewGlobalCtrl(NewWPACtrl(c, time.Second), time.Second)
+}
+
+func TestGlobalInterfaces(t *testing.T) {
+	mock, g := NewGlobalTest(t)
+
+	mock.Expect("INTERFACE_ADD wlan1\t/etc/wpa_supplicant/wlan1.conf\tnl80211\t/var/run/wpa_supplicant", "OK")
+	err := g.InterfaceAdd(InterfaceParams{
+		Ifname:        "wlan1",
+		ConfigFile:    "/etc/wpa_supplicant/wlan1.conf",
+		Driver:        "nl80211",
+		CtrlInterface: "/var/run/wpa_supplicant",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.InterfaceAdd(InterfaceParams{Ifname: "wlan0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.InterfaceAdd(InterfaceParams{Ifname: "wlan2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.InterfaceRemove("wlan2"); err != nil {
+		t.Fatal(err)
+	}
+	ifaces, err := g.Interfaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ifaces, []string{"wlan0"}) {
+		t.Fatal("wrong interfaces", ifaces)
+	}
+	if err := g.InterfaceRemove("wlan2"); err == nil {
+		t.Fatal("expected error removing missing interface")
+	}
+}
+
+func TestGlobalInterfaceCtrl(t *testing.T) {
+	mock, g := NewGlobalTest(t)
+
+	wlan0 := g.Interface("wlan0")
+	wlan1 := g.Interface("wlan1")
+
+	if err := wlan0.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wlan1.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.Expect("IFNAME=wlan0 ADD_NETWORK", "3")
+	id, err := wlan0.AddNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "3" {
+		t.Fatal("wrong id", id)
+	}
+
+	mock.SendUnsol("IFNAME=wlan1 <2>CTRL-EVENT-CONNECTED - Connection to 00:1a:dd:18:a4:25 completed [id=0 id_str=]")
+
+	select {
+	case evt := <-wlan1.Events():
+		if _, ok := evt.(*OnConnectedEvent); !ok {
+			t.Fatalf("wrong event %+v", evt)
+		}
+	case evt := <-wlan0.Events():
+		t.Fatalf("event for wrong interface %+v", evt)
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+}
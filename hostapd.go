@@ -0,0 +1,329 @@
+package wpa
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Wrap WPACtrl with commands for hostapd
+type HostapdCtrl struct {
+	ctrl   Ctrl
+	events chan HostapdEvent
+}
+
+// HostapdEvent is the same interface as WPASupplicantEvent, so hostapd events
+// share its queueing and the events common to both, like WPS and DPP.
+type HostapdEvent = WPASupplicantEvent
+
+// OnAPStaConnectedEvent is AP-STA-CONNECTED, sent when a station has associated
+// and completed authentication.
+type OnAPStaConnectedEvent struct {
+	baseEvent
+	Addr MAC
+}
+
+// OnAPStaDisconnectedEvent is AP-STA-DISCONNECTED.
+type OnAPStaDisconnectedEvent struct {
+	baseEvent
+	Addr MAC
+}
+
+type OnAPEnabledEvent struct{ baseEvent }
+type OnAPDisabledEvent struct{ baseEvent }
+
+// OnAPEAPEvent is any of the CTRL-EVENT-EAP-* events hostapd sends while
+// authenticating a station.
+type OnAPEAPEvent struct {
+	baseEvent
+	// Type is the part of the event name after CTRL-EVENT-EAP-, e.g. STARTED,
+	// SUCCESS, FAILURE, PROPOSED-METHOD.
+	Type string
+	Addr MAC
+}
+
+func NewOnAPEAPEvent(msg string) *OnAPEAPEvent {
+	name := strings.Fields(msg)[0]
+	return &OnAPEAPEvent{
+		baseEvent: baseEvent{msg},
+		Type:      strings.TrimPrefix(name, "CTRL-EVENT-EAP-"),
+		Addr:      firstMAC(msg),
+	}
+}
+
+func NewHostapdCtrl(ctrl Ctrl, cmdTimeout time.Duration) *HostapdCtrl {
+	apCtrl := &HostapdCtrl{
+		ctrl:   ctrl,
+		events: make(chan HostapdEvent),
+	}
+
+	queue := make(chan HostapdEvent)
+	go forwardEvents(queue, apCtrl.events)
+	go func() {
+		defer close(queue)
+		for msg := range ctrl.Unsolicited() {
+			queue <- parseHostapdEvent(msg)
+		}
+	}()
+
+	return apCtrl
+}
+
+func parseHostapdEvent(msg string) HostapdEvent {
+	if evt := parseWPSEvent(msg); evt != nil {
+		return evt
+	}
+	if evt := parseDPPEvent(msg); evt != nil {
+		return evt
+	}
+	switch {
+	case strings.HasPrefix(msg, "AP-STA-CONNECTED"):
+		return &OnAPStaConnectedEvent{baseEvent: baseEvent{msg}, Addr: firstMAC(msg)}
+	case strings.HasPrefix(msg, "AP-STA-DISCONNECTED"):
+		return &OnAPStaDisconnectedEvent{baseEvent: baseEvent{msg}, Addr: firstMAC(msg)}
+	case strings.HasPrefix(msg, "AP-ENABLED"):
+		return &OnAPEnabledEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "AP-DISABLED"):
+		return &OnAPDisabledEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "CTRL-EVENT-EAP-"):
+		return NewOnAPEAPEvent(msg)
+	case strings.HasPrefix(msg, "CTRL-EVENT-CHANNEL-SWITCH"),
+		strings.HasPrefix(msg, "CTRL-EVENT-STARTED-CHANNEL-SWITCH"):
+		return NewOnChannelSwitchEvent(msg)
+	case strings.HasPrefix(msg, "AP-CSA-FINISHED"):
+		return NewOnAPCSAFinishedEvent(msg)
+	case strings.HasPrefix(msg, "ACS-STARTED"):
+		return &OnACSStartedEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "ACS-COMPLETED"):
+		return NewOnACSCompletedEvent(msg)
+	case strings.HasPrefix(msg, "ACS-FAILED"):
+		return &OnACSFailedEvent{baseEvent: baseEvent{msg}}
+	}
+	return &OnEvent{baseEvent: baseEvent{msg}}
+}
+
+// Events returns every event. Up to eventBacklog events are held while it
+// isn't read.
+func (c *HostapdCtrl) Events() <-chan HostapdEvent {
+	return c.events
+}
+
+func (c *HostapdCtrl) Close() {
+	c.ctrl.Close()
+}
+
+func (c *HostapdCtrl) Ctrl() Ctrl {
+	return c.ctrl
+}
+
+// HostapdBSS is one of the BSSes listed in STATUS.
+type HostapdBSS struct {
+	Ifname string
+	BSSID  MAC
+	SSID   string
+	NumSta int
+}
+
+// HostapdStatus is the response to STATUS.
+type HostapdStatus struct {
+	// State is the interface state, e.g. ENABLED, DISABLED, ACS, HT_SCAN, DFS.
+	State            string
+	Phy              string
+	Freq             int
+	Channel          int
+	SecondaryChannel int
+	IEEE80211N       bool
+	IEEE80211AC      bool
+	IEEE80211AX      bool
+	BeaconInt        int
+	DTIMPeriod       int
+	MaxTxPower       int
+	BSS              []HostapdBSS
+	Raw              map[string]string
+}
+
+func (c *HostapdCtrl) Status() (*HostapdStatus, error) {
+	rsp, err := c.ctrl.FailCommand("STATUS")
+	if err != nil {
+		return nil, err
+	}
+	kv := parseKeyValues(rsp)
+	st := &HostapdStatus{
+		State:            kv["state"],
+		Phy:              kv["phy"],
+		Freq:             atoi(kv["freq"]),
+		Channel:          atoi(kv["channel"]),
+		SecondaryChannel: atoi(kv["secondary_channel"]),
+		IEEE80211N:       kv["ieee80211n"] == "1",
+		IEEE80211AC:      kv["ieee80211ac"] == "1",
+		IEEE80211AX:      kv["ieee80211ax"] == "1",
+		BeaconInt:        atoi(kv["beacon_int"]),
+		DTIMPeriod:       atoi(kv["dtim_period"]),
+		MaxTxPower:       atoi(kv["max_txpower"]),
+		Raw:              kv,
+	}
+	for i := 0; ; i++ {
+		ifname, ok := kv[fmt.Sprintf("bss[%d]", i)]
+		if !ok {
+			break
+		}
+		bssid, _ := ParseMAC(kv[fmt.Sprintf("bssid[%d]", i)])
+		st.BSS = append(st.BSS, HostapdBSS{
+			Ifname: ifname,
+			BSSID:  bssid,
+			SSID:   kv[fmt.Sprintf("ssid[%d]", i)],
+			NumSta: atoi(kv[fmt.Sprintf("num_sta[%d]", i)]),
+		})
+	}
+	return st, nil
+}
+
+// Station is the per-station information from STA, STA-FIRST and STA-NEXT.
+type Station struct {
+	Addr MAC
+	// Flags are the bracketed flags, without brackets, e.g. AUTH, ASSOC, AUTHORIZED.
+	Flags         []string
+	AID           int
+	RxPackets     uint64
+	TxPackets     uint64
+	RxBytes       uint64
+	TxBytes       uint64
+	InactiveMsec  int
+	Signal        int
+	RxRateInfo    string
+	TxRateInfo    string
+	ConnectedTime int
+	Raw           map[string]string
+}
+
+// HasFlag reports whether flag (e.g. "AUTHORIZED") is set for the station.
+func (s *Station) HasFlag(flag string) bool {
+	for _, f := range s.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func parseU64(s string) uint64 {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
+
+// parseStation parses a station response. The first line is the address, the
+// rest are key=value pairs. An empty response means there was no station.
+func parseStation(rsp string) (*Station, error) {
+	if rsp == "" {
+		return nil, nil
+	}
+	lines := strings.SplitN(rsp, "\n", 2)
+	addr, err := ParseMAC(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return nil, fmt.Errorf("bad station response: %v", err)
+	}
+	kv := map[string]string{}
+	if len(lines) > 1 {
+		kv = parseKeyValues(lines[1])
+	}
+	var flags []string
+	for _, f := range strings.Split(kv["flags"], "]") {
+		if f = strings.TrimPrefix(f, "["); f != "" {
+			flags = append(flags, f)
+		}
+	}
+	return &Station{
+		Addr:          addr,
+		Flags:         flags,
+		AID:           atoi(kv["aid"]),
+		RxPackets:     parseU64(kv["rx_packets"]),
+		TxPackets:     parseU64(kv["tx_packets"]),
+		RxBytes:       parseU64(kv["rx_bytes"]),
+		TxBytes:       parseU64(kv["tx_bytes"]),
+		InactiveMsec:  atoi(kv["inactive_msec"]),
+		Signal:        atoi(kv["signal"]),
+		RxRateInfo:    kv["rx_rate_info"],
+		TxRateInfo:    kv["tx_rate_info"],
+		ConnectedTime: atoi(kv["connected_time"]),
+		Raw:           kv,
+	}, nil
+}
+
+// Station returns information about a single associated station.
+func (c *HostapdCtrl) Station(addr MAC) (*Station, error) {
+	rsp, err := c.ctrl.FailCommand(fmt.Sprintf("STA %s", addr))
+	if err != nil {
+		return nil, err
+	}
+	sta, err := parseStation(rsp)
+	if err != nil {
+		return nil, err
+	}
+	if sta == nil {
+		return nil, fmt.Errorf("no station %s", addr)
+	}
+	return sta, nil
+}
+
+// Stations returns every associated station, walking STA-FIRST and STA-NEXT
+// the same way hostapd_cli all_sta does.
+func (c *HostapdCtrl) Stations() ([]*Station, error) {
+	var stations []*Station
+	cmd := "STA-FIRST"
+	for {
+		rsp, err := c.ctrl.FailCommand(cmd)
+		if err != nil {
+			return nil, err
+		}
+		sta, err := parseStation(rsp)
+		if err != nil {
+			return nil, err
+		}
+		if sta == nil {
+			return stations, nil
+		}
+		stations = append(stations, sta)
+		cmd = fmt.Sprintf("STA-NEXT %s", sta.Addr)
+	}
+}
+
+func reasonArg(reason int) string {
+	if reason == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" reason=%d", reason)
+}
+
+// Deauthenticate kicks a station. A zero reason uses hostapd's default.
+func (c *HostapdCtrl) Deauthenticate(addr MAC, reason int) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("DEAUTHENTICATE %s%s", addr, reasonArg(reason)))
+}
+
+// Disassociate disassociates a station. A zero reason uses hostapd's default.
+func (c *HostapdCtrl) Disassociate(addr MAC, reason int) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("DISASSOCIATE %s%s", addr, reasonArg(reason)))
+}
+
+func (c *HostapdCtrl) Enable() error {
+	return c.ctrl.OkCommand("ENABLE")
+}
+
+func (c *HostapdCtrl) Disable() error {
+	return c.ctrl.OkCommand("DISABLE")
+}
+
+// Reload rereads the configuration file.
+func (c *HostapdCtrl) Reload() error {
+	return c.ctrl.OkCommand("RELOAD")
+}
+
+// Set changes a runtime configuration parameter.
+func (c *HostapdCtrl) Set(name, value string) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("SET %s %s", name, value))
+}
+
+// Get reads a runtime configuration parameter.
+func (c *HostapdCtrl) Get(name string) (string, error) {
+	return c.ctrl.FailCommand(fmt.Sprintf("GET %s", name))
+}
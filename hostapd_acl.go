@@ -0,0 +1,96 @@
+package wpa
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ACL selects one of hostapd's MAC address access control lists.
+type ACL string
+
+const (
+	// AcceptACL lists the stations allowed when macaddr_acl=1.
+	AcceptACL ACL = "ACCEPT_ACL"
+	// DenyACL lists the stations refused when macaddr_acl=0.
+	DenyACL ACL = "DENY_ACL"
+)
+
+type ACLEntry struct {
+	Addr   MAC
+	VLANID int
+}
+
+// ACLAdd adds addr to the list. A non-zero vlanID assigns the station to that VLAN.
+func (c *HostapdCtrl) ACLAdd(acl ACL, addr MAC, vlanID int) error {
+	cmd := fmt.Sprintf("%s ADD_MAC %s", acl, addr)
+	if vlanID != 0 {
+		cmd += fmt.Sprintf(" VLAN_ID=%d", vlanID)
+	}
+	return c.ctrl.OkCommand(cmd)
+}
+
+func (c *HostapdCtrl) ACLDel(acl ACL, addr MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("%s DEL_MAC %s", acl, addr))
+}
+
+func (c *HostapdCtrl) ACLClear(acl ACL) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("%s CLEAR", acl))
+}
+
+// ACLShow lists the entries in the list.
+func (c *HostapdCtrl) ACLShow(acl ACL) ([]ACLEntry, error) {
+	rsp, err := c.ctrl.FailCommand(fmt.Sprintf("%s SHOW", acl))
+	if err != nil {
+		return nil, err
+	}
+	var entries []ACLEntry
+	for _, line := range strings.Split(rsp, "\n") {
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		addr, err := ParseMAC(f[0])
+		if err != nil {
+			return nil, fmt.Errorf("bad ACL entry %q: %v", line, err)
+		}
+		e := ACLEntry{Addr: addr}
+		if len(f) > 1 {
+			e.VLANID = atoi(strings.TrimPrefix(f[1], "VLAN_ID="))
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// AllowOnly switches the AP to macaddr_acl=1 and replaces the accept list with
+// addrs, so only those stations can associate. Changing macaddr_acl doesn't
+// affect stations that are already associated, so any others are
+// deauthenticated.
+func (c *HostapdCtrl) AllowOnly(addrs ...MAC) error {
+	if err := c.ACLClear(AcceptACL); err != nil {
+		return err
+	}
+	allowed := make(map[MAC]bool, len(addrs))
+	for _, addr := range addrs {
+		if err := c.ACLAdd(AcceptACL, addr, 0); err != nil {
+			return err
+		}
+		allowed[addr] = true
+	}
+	if err := c.Set("macaddr_acl", "1"); err != nil {
+		return err
+	}
+	stations, err := c.Stations()
+	if err != nil {
+		return err
+	}
+	for _, sta := range stations {
+		if allowed[sta.Addr] {
+			continue
+		}
+		if err := c.Deauthenticate(sta.Addr, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
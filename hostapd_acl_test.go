@@ -0,0 +1,92 @@
+package wpa
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHostapdACL(t *testing.T) {
+	_, ctrl := NewHostapdTest(t)
+
+	a := MustParseMAC("02:00:00:00:01:00")
+	b := MustParseMAC("02:00:00:00:02:00")
+
+	if err := ctrl.ACLAdd(DenyACL, a, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.ACLAdd(DenyACL, b, 7); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ctrl.ACLShow(DenyACL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, []ACLEntry{{a, 0}, {b, 7}}) {
+		t.Fatalf("wrong entries %+v", entries)
+	}
+
+	if err := ctrl.ACLDel(DenyACL, a); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = ctrl.ACLShow(DenyACL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, []ACLEntry{{b, 7}}) {
+		t.Fatalf("wrong entries after delete %+v", entries)
+	}
+
+	if err := ctrl.ACLClear(DenyACL); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = ctrl.ACLShow(DenyACL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected empty list %+v", entries)
+	}
+}
+
+func TestHostapdAllowOnly(t *testing.T) {
+	mock, ctrl := NewHostapdTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	phone := MustParseMAC("02:00:00:00:09:00")
+	laptop := MustParseMAC("02:00:00:00:01:00")
+	if err := ctrl.ACLAdd(AcceptACL, laptop, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range []MAC{laptop, phone} {
+		mock.AnnounceStaConnected(addr.String())
+		if _, ok := nextHostapdEvent(t, ctrl).(*OnAPStaConnectedEvent); !ok {
+			t.Fatal("expected AP-STA-CONNECTED")
+		}
+	}
+
+	if err := ctrl.AllowOnly(phone); err != nil {
+		t.Fatal(err)
+	}
+	if evt, ok := nextHostapdEvent(t, ctrl).(*OnAPStaDisconnectedEvent); !ok || evt.Addr != laptop {
+		t.Fatalf("wrong event %+v", evt)
+	}
+	stas, err := ctrl.Stations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stas) != 1 || stas[0].Addr != phone {
+		t.Fatalf("wrong stations %+v", stas)
+	}
+	entries, err := ctrl.ACLShow(AcceptACL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, []ACLEntry{{phone, 0}}) {
+		t.Fatalf("wrong entries %+v", entries)
+	}
+	if v := mock.HostapdParam("macaddr_acl"); v != "1" {
+		t.Fatal("macaddr_acl not set", v)
+	}
+}
@@ -0,0 +1,179 @@
+package wpa
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FreqToChannel converts a frequency in MHz to a channel number, or 0 if the
+// frequency isn't in the 2.4, 5 or 6 GHz bands.
+func FreqToChannel(freq int) int {
+	switch {
+	case freq == 2484:
+		return 14
+	case freq >= 2412 && freq < 2484:
+		return (freq - 2407) / 5
+	case freq == 5935:
+		return 2
+	case freq > 5950 && freq <= 7115:
+		return (freq - 5950) / 5
+	case freq >= 5000 && freq < 5950:
+		return (freq - 5000) / 5
+	}
+	return 0
+}
+
+// ChanSwitchParams are the CHAN_SWITCH parameters. Zero values are omitted.
+type ChanSwitchParams struct {
+	// CSCount is the number of beacons announcing the switch before it happens.
+	CSCount int
+	Freq    int
+	// SecChannelOffset is -1 or 1 for 40 MHz operation below or above Freq.
+	SecChannelOffset int
+	CenterFreq1      int
+	CenterFreq2      int
+	// Bandwidth in MHz.
+	Bandwidth int
+	// BlockTx asks stations to stop transmitting until the switch.
+	BlockTx bool
+	HT      bool
+	VHT     bool
+	HE      bool
+}
+
+func (p ChanSwitchParams) String() string {
+	args := []string{fmt.Sprintf("%d %d", p.CSCount, p.Freq)}
+	addInt := func(name string, v int) {
+		if v != 0 {
+			args = append(args, fmt.Sprintf("%s=%d", name, v))
+		}
+	}
+	addInt("sec_channel_offset", p.SecChannelOffset)
+	addInt("center_freq1", p.CenterFreq1)
+	addInt("center_freq2", p.CenterFreq2)
+	addInt("bandwidth", p.Bandwidth)
+	if p.BlockTx {
+		args = append(args, "blocktx")
+	}
+	if p.HT {
+		args = append(args, "ht")
+	}
+	if p.VHT {
+		args = append(args, "vht")
+	}
+	if p.HE {
+		args = append(args, "he")
+	}
+	return strings.Join(args, " ")
+}
+
+// ChanSwitch moves the AP to another channel, announcing it with channel switch
+// announcements. Completion is reported with AP-CSA-FINISHED.
+func (c *HostapdCtrl) ChanSwitch(p ChanSwitchParams) error {
+	if p.CSCount <= 0 || p.Freq <= 0 {
+		return errors.New("cs_count and freq are required")
+	}
+	return c.ctrl.OkCommand("CHAN_SWITCH " + p.String())
+}
+
+// ACSResult is the channel automatic channel selection settled on.
+type ACSResult struct {
+	Freq             int
+	Channel          int
+	SecondaryChannel int
+	// VHT center frequency segment indexes and channel width, when 802.11ac
+	// is enabled.
+	VHTCenterSeg0 int
+	VHTCenterSeg1 int
+	VHTChWidth    int
+}
+
+// ACSResult reads the selected channel from STATUS. It fails while ACS is
+// still running.
+func (c *HostapdCtrl) ACSResult() (*ACSResult, error) {
+	st, err := c.Status()
+	if err != nil {
+		return nil, err
+	}
+	if st.State == "ACS" {
+		return nil, errors.New("ACS in progress")
+	}
+	if st.State != "ENABLED" {
+		return nil, fmt.Errorf("interface is %s", st.State)
+	}
+	return &ACSResult{
+		Freq:             st.Freq,
+		Channel:          st.Channel,
+		SecondaryChannel: st.SecondaryChannel,
+		VHTCenterSeg0:    atoi(st.Raw["vht_oper_centr_freq_seg0_idx"]),
+		VHTCenterSeg1:    atoi(st.Raw["vht_oper_centr_freq_seg1_idx"]),
+		VHTChWidth:       atoi(st.Raw["vht_oper_chwidth"]),
+	}, nil
+}
+
+// OnChannelSwitchEvent is CTRL-EVENT-CHANNEL-SWITCH, or
+// CTRL-EVENT-STARTED-CHANNEL-SWITCH when Started is set.
+type OnChannelSwitchEvent struct {
+	baseEvent
+	Started   bool
+	Freq      int
+	HTEnabled bool
+	ChOffset  int
+	// ChWidth is the width in MHz as reported, e.g. "20", "40", "80+80".
+	ChWidth string
+	CF1     int
+	CF2     int
+	DFS     bool
+}
+
+func NewOnChannelSwitchEvent(msg string) *OnChannelSwitchEvent {
+	f := parseEventFields(msg)
+	return &OnChannelSwitchEvent{
+		baseEvent: baseEvent{msg},
+		Started:   strings.HasPrefix(msg, "CTRL-EVENT-STARTED-CHANNEL-SWITCH"),
+		Freq:      atoi(f["freq"]),
+		HTEnabled: f["ht_enabled"] == "1",
+		ChOffset:  atoi(f["ch_offset"]),
+		ChWidth:   f["ch_width"],
+		CF1:       atoi(f["cf1"]),
+		CF2:       atoi(f["cf2"]),
+		DFS:       f["dfs"] == "1",
+	}
+}
+
+// OnAPCSAFinishedEvent is AP-CSA-FINISHED, sent when a channel switch completes.
+type OnAPCSAFinishedEvent struct {
+	baseEvent
+	Freq int
+	DFS  bool
+}
+
+func NewOnAPCSAFinishedEvent(msg string) *OnAPCSAFinishedEvent {
+	f := parseEventFields(msg)
+	return &OnAPCSAFinishedEvent{
+		baseEvent: baseEvent{msg},
+		Freq:      atoi(f["freq"]),
+		DFS:       f["dfs"] == "1",
+	}
+}
+
+type OnACSStartedEvent struct{ baseEvent }
+type OnACSFailedEvent struct{ baseEvent }
+
+// OnACSCompletedEvent is ACS-COMPLETED, sent when automatic channel selection
+// has picked a channel.
+type OnACSCompletedEvent struct {
+	baseEvent
+	Freq    int
+	Channel int
+}
+
+func NewOnACSCompletedEvent(msg string) *OnACSCompletedEvent {
+	f := parseEventFields(msg)
+	return &OnACSCompletedEvent{
+		baseEvent: baseEvent{msg},
+		Freq:      atoi(f["freq"]),
+		Channel:   atoi(f["channel"]),
+	}
+}
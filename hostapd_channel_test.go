@@ -0,0 +1,74 @@
+package wpa
+
+import "testing"
+
+func TestFreqToChannel(t *testing.T) {
+	cases := map[int]int{2412: 1, 2437: 6, 2484: 14, 5180: 36, 5825: 165, 5955: 1, 6115: 33, 900: 0}
+	for freq, ch := range cases {
+		if FreqToChannel(freq) != ch {
+			t.Fatal("wrong channel for", freq, FreqToChannel(freq))
+		}
+	}
+}
+
+func TestChanSwitchParams(t *testing.T) {
+	p := ChanSwitchParams{
+		CSCount:          5,
+		Freq:             5180,
+		SecChannelOffset: 1,
+		CenterFreq1:      5210,
+		Bandwidth:        80,
+		BlockTx:          true,
+		HT:               true,
+		VHT:              true,
+	}
+	expect := "5 5180 sec_channel_offset=1 center_freq1=5210 bandwidth=80 blocktx ht vht"
+	if p.String() != expect {
+		t.Fatal("wrong params", p.String())
+	}
+}
+
+func TestChanSwitch(t *testing.T) {
+	_, ctrl := NewHostapdTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ctrl.ChanSwitch(ChanSwitchParams{Freq: 2412}); err == nil {
+		t.Fatal("expected error without cs_count")
+	}
+	if err := ctrl.ChanSwitch(ChanSwitchParams{CSCount: 5, Freq: 2412, HT: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, ok := nextHostapdEvent(t, ctrl).(*OnChannelSwitchEvent)
+	if !ok || cs.Started || cs.Freq != 2412 || !cs.HTEnabled || cs.ChWidth != "20" || cs.CF1 != 2412 {
+		t.Fatalf("wrong channel switch event %+v", cs)
+	}
+	fin, ok := nextHostapdEvent(t, ctrl).(*OnAPCSAFinishedEvent)
+	if !ok || fin.Freq != 2412 || fin.DFS {
+		t.Fatalf("wrong csa finished event %+v", fin)
+	}
+
+	res, err := ctrl.ACSResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Freq != 2412 || res.Channel != 1 {
+		t.Fatalf("wrong result %+v", res)
+	}
+}
+
+func TestACSEvents(t *testing.T) {
+	evt, ok := parseHostapdEvent("ACS-COMPLETED freq=5180 channel=36").(*OnACSCompletedEvent)
+	if !ok || evt.Freq != 5180 || evt.Channel != 36 {
+		t.Fatalf("wrong event %+v", evt)
+	}
+	if _, ok := parseHostapdEvent("ACS-FAILED").(*OnACSFailedEvent); !ok {
+		t.Fatal("expected ACS-FAILED")
+	}
+	started, ok := parseHostapdEvent("CTRL-EVENT-STARTED-CHANNEL-SWITCH freq=5500 ht_enabled=1 ch_offset=1 ch_width=80 MHz cf1=5530 cf2=0 dfs=1").(*OnChannelSwitchEvent)
+	if !ok || !started.Started || !started.DFS || started.ChWidth != "80" {
+		t.Fatalf("wrong event %+v", started)
+	}
+}
@@ -0,0 +1,297 @@
+package wpa
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// HostapdParam is a hostapd.conf setting without a typed HostapdConfig field.
+type HostapdParam struct {
+	Name  string
+	Value string
+}
+
+// HostapdConfig is a typed hostapd.conf.
+// Settings without a field are kept in Extra, in order, so parsing and writing
+// a config doesn't lose them. Comments are not preserved.
+type HostapdConfig struct {
+	Interface string
+	Driver    string
+	SSID      string
+	// HWMode is a, b, g, ad or any.
+	HWMode string
+	// Channel 0 selects the channel automatically (ACS).
+	Channel     int
+	CountryCode string
+	// WPA is a bitfield: 1 for WPA, 2 for WPA2/RSN. 0 disables WPA.
+	WPA           int
+	WPAPassphrase string
+	WPAKeyMgmt    []string
+	RSNPairwise   []string
+	IEEE80211N    bool
+	IEEE80211AC   bool
+	IEEE80211AX   bool
+	// IgnoreBroadcastSSID is 0 to advertise the SSID, 1 to send an empty SSID
+	// and 2 to send a zero-filled SSID of the same length.
+	IgnoreBroadcastSSID int
+	CtrlInterface       string
+	// MACAddrACL is 0 to accept unless in DenyMACFile, 1 to deny unless in
+	// AcceptMACFile, and 2 to use RADIUS.
+	MACAddrACL    int
+	AcceptMACFile string
+	DenyMACFile   string
+
+	Extra []HostapdParam
+}
+
+func boolParam(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// params returns the settings in the order they're written.
+func (c *HostapdConfig) params() []HostapdParam {
+	var p []HostapdParam
+	add := func(name, value string) {
+		if value != "" {
+			p = append(p, HostapdParam{name, value})
+		}
+	}
+	addInt := func(name string, value int, always bool) {
+		if value != 0 || always {
+			add(name, strconv.Itoa(value))
+		}
+	}
+	addBool := func(name string, value bool) {
+		if value {
+			add(name, boolParam(value))
+		}
+	}
+
+	add("interface", c.Interface)
+	add("driver", c.Driver)
+	add("ctrl_interface", c.CtrlInterface)
+	add("ssid", c.SSID)
+	add("country_code", c.CountryCode)
+	add("hw_mode", c.HWMode)
+	addInt("channel", c.Channel, true)
+	addBool("ieee80211n", c.IEEE80211N)
+	addBool("ieee80211ac", c.IEEE80211AC)
+	addBool("ieee80211ax", c.IEEE80211AX)
+	addInt("ignore_broadcast_ssid", c.IgnoreBroadcastSSID, false)
+	addInt("macaddr_acl", c.MACAddrACL, false)
+	add("accept_mac_file", c.AcceptMACFile)
+	add("deny_mac_file", c.DenyMACFile)
+	addInt("wpa", c.WPA, false)
+	add("wpa_passphrase", c.WPAPassphrase)
+	add("wpa_key_mgmt", strings.Join(c.WPAKeyMgmt, " "))
+	add("rsn_pairwise", strings.Join(c.RSNPairwise, " "))
+	return append(p, c.Extra...)
+}
+
+// WriteTo writes the config in hostapd.conf format. The output only depends on
+// the config, so generated files can be compared byte for byte.
+func (c *HostapdConfig) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	for _, p := range c.params() {
+		fmt.Fprintf(&buf, "%s=%s\n", p.Name, p.Value)
+	}
+	return buf.WriteTo(w)
+}
+
+// ParseHostapdConfig reads a hostapd.conf.
+func ParseHostapdConfig(r io.Reader) (*HostapdConfig, error) {
+	c := &HostapdConfig{}
+	scanner := bufio.NewScanner(r)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("line %d: invalid line %q", lineno, line)
+		}
+		if err := c.set(line[:i], line[i+1:]); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *HostapdConfig) set(name, value string) error {
+	var err error
+	atoiErr := func(s string) int {
+		var n int
+		n, err = strconv.Atoi(s)
+		return n
+	}
+	switch name {
+	case "interface":
+		c.Interface = value
+	case "driver":
+		c.Driver = value
+	case "ctrl_interface":
+		c.CtrlInterface = value
+	case "ssid":
+		c.SSID = value
+	case "country_code":
+		c.CountryCode = value
+	case "hw_mode":
+		c.HWMode = value
+	case "channel":
+		if value == "acs_survey" {
+			c.Channel = 0
+		} else {
+			c.Channel = atoiErr(value)
+		}
+	case "ieee80211n":
+		c.IEEE80211N = value == "1"
+	case "ieee80211ac":
+		c.IEEE80211AC = value == "1"
+	case "ieee80211ax":
+		c.IEEE80211AX = value == "1"
+	case "ignore_broadcast_ssid":
+		c.IgnoreBroadcastSSID = atoiErr(value)
+	case "macaddr_acl":
+		c.MACAddrACL = atoiErr(value)
+	case "accept_mac_file":
+		c.AcceptMACFile = value
+	case "deny_mac_file":
+		c.DenyMACFile = value
+	case "wpa":
+		c.WPA = atoiErr(value)
+	case "wpa_passphrase":
+		c.WPAPassphrase = value
+	case "wpa_key_mgmt":
+		c.WPAKeyMgmt = strings.Fields(value)
+	case "rsn_pairwise":
+		c.RSNPairwise = strings.Fields(value)
+	default:
+		c.Extra = append(c.Extra, HostapdParam{name, value})
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// hasExtra reports whether any of names is set in Extra.
+func (c *HostapdConfig) hasExtra(names ...string) bool {
+	for _, p := range c.Extra {
+		for _, name := range names {
+			if p.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasAny(list []string, values ...string) bool {
+	for _, l := range list {
+		for _, v := range values {
+			if l == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Validate checks for missing settings and combinations hostapd would reject
+// or silently change. All problems are reported in a single error.
+func (c *HostapdConfig) Validate() error {
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Interface == "" {
+		fail("interface is required")
+	}
+	if len(c.SSID) == 0 || len(c.SSID) > 32 {
+		fail("ssid must be 1 to 32 bytes")
+	}
+	if c.CountryCode != "" && (len(c.CountryCode) != 2 || strings.ToUpper(c.CountryCode) != c.CountryCode) {
+		fail("country_code must be two upper case letters")
+	}
+
+	switch c.HWMode {
+	case "", "g", "b":
+		if c.Channel != 0 && (c.Channel < 1 || c.Channel > 14) {
+			fail("channel %d is not a 2.4 GHz channel", c.Channel)
+		}
+		if c.IEEE80211AC {
+			fail("ieee80211ac requires hw_mode=a")
+		}
+	case "a":
+		// With op_class, channel may be a 6 GHz channel number, which
+		// overlaps the 2.4 GHz ones.
+		if c.Channel != 0 && !c.hasExtra("op_class") && (c.Channel < 32 || c.Channel > 177) {
+			fail("channel %d is not a 5 GHz channel", c.Channel)
+		}
+	case "ad", "any":
+	default:
+		fail("unknown hw_mode %q", c.HWMode)
+	}
+	if c.IEEE80211AC && !c.IEEE80211N {
+		fail("ieee80211ac requires ieee80211n")
+	}
+
+	if c.IgnoreBroadcastSSID < 0 || c.IgnoreBroadcastSSID > 2 {
+		fail("ignore_broadcast_ssid must be 0, 1 or 2")
+	}
+	switch c.MACAddrACL {
+	case 0, 2:
+	case 1:
+		if c.AcceptMACFile == "" {
+			fail("macaddr_acl=1 requires accept_mac_file")
+		}
+	default:
+		fail("macaddr_acl must be 0, 1 or 2")
+	}
+
+	if c.WPA == 0 {
+		if len(c.WPAKeyMgmt) > 0 || c.WPAPassphrase != "" {
+			fail("wpa_key_mgmt and wpa_passphrase require wpa")
+		}
+	} else {
+		if c.WPA < 0 || c.WPA > 3 {
+			fail("wpa must be 1, 2 or 3")
+		}
+		if len(c.WPAKeyMgmt) == 0 {
+			fail("wpa requires wpa_key_mgmt")
+		}
+		if hasAny(c.WPAKeyMgmt, "WPA-PSK", "WPA-PSK-SHA256", "FT-PSK", "SAE") &&
+			c.WPAPassphrase == "" && !c.hasExtra("wpa_psk", "wpa_psk_file", "sae_password") {
+			fail("PSK and SAE key_mgmt require wpa_passphrase, wpa_psk, wpa_psk_file or sae_password")
+		}
+		if n := len(c.WPAPassphrase); n > 0 && (n < 8 || n > 63) {
+			fail("wpa_passphrase must be 8 to 63 characters")
+		}
+		if c.IEEE80211N && c.WPA&2 == 0 {
+			fail("ieee80211n requires wpa=2; WPA1-only disables HT")
+		}
+		if c.IEEE80211N && len(c.RSNPairwise) > 0 && !hasAny(c.RSNPairwise, "CCMP", "GCMP", "CCMP-256", "GCMP-256") {
+			fail("ieee80211n requires a CCMP or GCMP rsn_pairwise cipher")
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package wpa
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func provisioningConfig() *HostapdConfig {
+	return &HostapdConfig{
+		Interface:     "wlan0",
+		Driver:        "nl80211",
+		CtrlInterface: "/var/run/hostapd",
+		SSID:          "device-setup",
+		CountryCode:   "US",
+		HWMode:        "g",
+		Channel:       6,
+		IEEE80211N:    true,
+		WPA:           2,
+		WPAPassphrase: "setup passphrase",
+		WPAKeyMgmt:    []string{"WPA-PSK"},
+		RSNPairwise:   []string{"CCMP"},
+		Extra:         []HostapdParam{{"auth_algs", "1"}},
+	}
+}
+
+const provisioningConf = `interface=wlan0
+driver=nl80211
+ctrl_interface=/var/run/hostapd
+ssid=device-setup
+country_code=US
+hw_mode=g
+channel=6
+ieee80211n=1
+wpa=2
+wpa_passphrase=setup passphrase
+wpa_key_mgmt=WPA-PSK
+rsn_pairwise=CCMP
+auth_algs=1
+`
+
+func TestHostapdConfigWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := provisioningConfig().WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != provisioningConf {
+		t.Fatalf("wrong output:\n%s", buf.String())
+	}
+}
+
+func TestHostapdConfigRoundTrip(t *testing.T) {
+	in := "# provisioning AP\n\n" + strings.Replace(provisioningConf, "channel=6", "channel=6\n# comment", 1)
+	cfg, err := ParseHostapdConfig(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg, provisioningConfig()) {
+		t.Fatalf("wrong config %+v", cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHostapdConfigParseError(t *testing.T) {
+	for _, in := range []string{"channel=six\n", "no equals\n"} {
+		if _, err := ParseHostapdConfig(strings.NewReader(in)); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
+
+func TestHostapdConfigValidate(t *testing.T) {
+	cases := map[string]func(c *HostapdConfig){
+		"ssid must be":               func(c *HostapdConfig) { c.SSID = "" },
+		"not a 2.4 GHz channel":      func(c *HostapdConfig) { c.Channel = 36 },
+		"not a 5 GHz channel":        func(c *HostapdConfig) { c.HWMode = "a" },
+		"ieee80211ac requires hw":    func(c *HostapdConfig) { c.IEEE80211AC = true },
+		"wpa_passphrase must be":     func(c *HostapdConfig) { c.WPAPassphrase = "short" },
+		"require wpa_passphrase":     func(c *HostapdConfig) { c.WPAPassphrase = "" },
+		"WPA1-only disables HT":      func(c *HostapdConfig) { c.WPA = 1 },
+		"CCMP or GCMP":               func(c *HostapdConfig) { c.RSNPairwise = []string{"TKIP"} },
+		"require wpa":                func(c *HostapdConfig) { c.WPA = 0 },
+		"requires accept_mac_file":   func(c *HostapdConfig) { c.MACAddrACL = 1 },
+		"country_code must be":       func(c *HostapdConfig) { c.CountryCode = "usa" },
+		"ignore_broadcast_ssid must": func(c *HostapdConfig) { c.IgnoreBroadcastSSID = 3 },
+	}
+	for expect, mutate := range cases {
+		cfg := provisioningConfig()
+		mutate(cfg)
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), expect) {
+			t.Fatalf("expected %q, got %v", expect, err)
+		}
+	}
+
+	acs := provisioningConfig()
+	acs.HWMode = "a"
+	acs.Channel = 0
+	acs.IEEE80211AC = true
+	if err := acs.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	sae := provisioningConfig()
+	sae.WPAPassphrase = ""
+	sae.WPAKeyMgmt = []string{"SAE"}
+	sae.Extra = append(sae.Extra, HostapdParam{"sae_password", strings.Repeat("long sae password ", 5)})
+	if err := sae.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	sixGHz := provisioningConfig()
+	sixGHz.HWMode = "a"
+	sixGHz.Channel = 5
+	sixGHz.Extra = append(sixGHz.Extra, HostapdParam{"op_class", "131"})
+	if err := sixGHz.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
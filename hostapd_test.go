@@ -0,0 +1,190 @@
+package wpa
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+func NewHostapdTest(t *testing.T) (*wpatest.WPAProcessMock, *HostapdCtrl) {
+	lc, c := NewTempConn(t)
+	mock := wpatest.NewHostapdProcessMock(t, lc)
+
+	bctrl := NewWPACtrl(c, 5*time.Second)
+
+	ctrl := NewHostapdCtrl(bctrl, time.Second)
+	return mock, ctrl
+}
+
+func nextHostapdEvent(t *testing.T, ctrl *HostapdCtrl) HostapdEvent {
+	select {
+	case evt := <-ctrl.Events():
+		return evt
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return nil
+}
+
+func TestParseMAC(t *testing.T) {
+	m, err := ParseMAC("02:00:00:00:01:0A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.String() != "02:00:00:00:01:0a" || m.IsZero() {
+		t.Fatal("wrong mac", m)
+	}
+	if _, err := ParseMAC("00:00:00:00:fe:80:00:00:00:00:00:00:02:00:5e:10:00:00:00:01"); err == nil {
+		t.Fatal("expected error for long address")
+	}
+}
+
+func TestHostapdStatus(t *testing.T) {
+	_, ctrl := NewHostapdTest(t)
+
+	st, err := ctrl.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.State != "ENABLED" || st.Channel != 6 || st.Freq != 2437 || !st.IEEE80211N || st.IEEE80211AC {
+		t.Fatalf("wrong status %+v", st)
+	}
+	if len(st.BSS) != 1 || st.BSS[0].SSID != "test" || st.BSS[0].BSSID != MustParseMAC("02:00:00:00:03:00") {
+		t.Fatalf("wrong bss %+v", st.BSS)
+	}
+}
+
+func TestHostapdStations(t *testing.T) {
+	mock, ctrl := NewHostapdTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	stas, err := ctrl.Stations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stas) != 0 {
+		t.Fatal("expected no stations", stas)
+	}
+
+	a := MustParseMAC("02:00:00:00:01:00")
+	b := MustParseMAC("02:00:00:00:02:00")
+	mock.AnnounceStaConnected(a.String())
+	mock.AnnounceStaConnected(b.String())
+	for _, addr := range []MAC{a, b} {
+		evt, ok := nextHostapdEvent(t, ctrl).(*OnAPStaConnectedEvent)
+		if !ok || evt.Addr != addr {
+			t.Fatalf("wrong event %+v", evt)
+		}
+	}
+
+	stas, err = ctrl.Stations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stas) != 2 || stas[0].Addr != a || stas[1].Addr != b {
+		t.Fatalf("wrong stations %+v", stas)
+	}
+	sta := stas[0]
+	if !sta.HasFlag("AUTHORIZED") || sta.RxBytes != 134221 || sta.Signal != -42 || sta.TxRateInfo != "650 mcs 7 shortGI" {
+		t.Fatalf("wrong station %+v", sta)
+	}
+
+	if err := ctrl.Deauthenticate(a, 3); err != nil {
+		t.Fatal(err)
+	}
+	if evt, ok := nextHostapdEvent(t, ctrl).(*OnAPStaDisconnectedEvent); !ok || evt.Addr != a {
+		t.Fatalf("wrong event %+v", evt)
+	}
+	if _, err := ctrl.Station(a); err == nil {
+		t.Fatal("expected deauthenticated station to be gone")
+	}
+	if _, err := ctrl.Station(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHostapdEnableDisable(t *testing.T) {
+	_, ctrl := NewHostapdTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ctrl.Disable(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := nextHostapdEvent(t, ctrl).(*OnAPDisabledEvent); !ok {
+		t.Fatal("expected AP-DISABLED")
+	}
+	if err := ctrl.Disable(); err == nil {
+		t.Fatal("expected error disabling twice")
+	}
+	if err := ctrl.Enable(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := nextHostapdEvent(t, ctrl).(*OnAPEnabledEvent); !ok {
+		t.Fatal("expected AP-ENABLED")
+	}
+	if err := ctrl.Reload(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHostapdSetGet(t *testing.T) {
+	_, ctrl := NewHostapdTest(t)
+
+	if err := ctrl.Set("wpa_passphrase", "secret passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.Set("wpa_key_mgmt", "WPA-PSK SAE"); err != nil {
+		t.Fatal(err)
+	}
+	v, err := ctrl.Get("wpa_key_mgmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "WPA-PSK SAE" {
+		t.Fatal("wrong value", v)
+	}
+	// GET only supports a few parameters, and never returns secrets.
+	if _, err := ctrl.Get("wpa_passphrase"); err == nil {
+		t.Fatal("expected error for unsupported GET")
+	}
+	if err := ctrl.Set("missing", "1"); err == nil {
+		t.Fatal("expected error for unknown param")
+	}
+}
+
+func TestHostapdEAPEvent(t *testing.T) {
+	evt, ok := parseHostapdEvent("CTRL-EVENT-EAP-SUCCESS 02:00:00:00:01:00").(*OnAPEAPEvent)
+	if !ok || evt.Type != "SUCCESS" || evt.Addr != MustParseMAC("02:00:00:00:01:00") {
+		t.Fatalf("wrong event %+v", evt)
+	}
+}
+
+func TestHostapdUnreadEvents(t *testing.T) {
+	mock, ctrl := NewHostapdTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	// More than WPACtrl buffers, with Events() not being read.
+	const n = 150
+	for i := 0; i < n; i++ {
+		mock.SendUnsol(fmt.Sprintf("<3>AP-STA-CONNECTED 02:00:00:00:%02x:%02x", i/256, i%256))
+	}
+	if _, err := ctrl.Status(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctrl.Stations(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if _, ok := nextHostapdEvent(t, ctrl).(*OnAPStaConnectedEvent); !ok {
+			t.Fatal("expected AP-STA-CONNECTED", i)
+		}
+	}
+}
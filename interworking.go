@@ -0,0 +1,428 @@
+package wpa
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ANQP info IDs for ANQPGet.
+const (
+	ANQPVenueName         = 258
+	ANQPRoamingConsortium = 261
+	ANQPNAIRealm          = 263
+	ANQP3GPPCellular      = 264
+	ANQPDomainName        = 268
+)
+
+// Hotspot 2.0 ANQP subtypes for HS20ANQPGet.
+const (
+	HS20OperatorFriendlyName = 3
+	HS20WANMetrics           = 4
+	HS20ConnectionCapability = 5
+)
+
+// InterworkingSelect matches the credentials against the APs that support
+// interworking, reporting each match with INTERWORKING-AP. With auto set the
+// best match is connected to.
+func (c *WPASupplicantCtrl) InterworkingSelect(auto bool) error {
+	if auto {
+		return c.ctrl.OkCommand("INTERWORKING_SELECT auto")
+	}
+	return c.ctrl.OkCommand("INTERWORKING_SELECT")
+}
+
+// InterworkingConnect connects to bssid using a matching credential.
+func (c *WPASupplicantCtrl) InterworkingConnect(bssid MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("INTERWORKING_CONNECT %s", bssid))
+}
+
+func joinInts(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, ",")
+}
+
+// ANQPGet queries bssid for the given ANQP info IDs. Each response is reported
+// with RX-ANQP, then ANQP-QUERY-DONE; read the results with ANQPResults.
+func (c *WPASupplicantCtrl) ANQPGet(bssid MAC, ids ...int) error {
+	if len(ids) == 0 {
+		return errors.New("no ANQP info IDs")
+	}
+	return c.ctrl.OkCommand(fmt.Sprintf("ANQP_GET %s %s", bssid, joinInts(ids)))
+}
+
+// HS20ANQPGet queries bssid for Hotspot 2.0 ANQP subtypes.
+func (c *WPASupplicantCtrl) HS20ANQPGet(bssid MAC, subtypes ...int) error {
+	if len(subtypes) == 0 {
+		return errors.New("no HS 2.0 subtypes")
+	}
+	return c.ctrl.OkCommand(fmt.Sprintf("HS20_ANQP_GET %s %s", bssid, joinInts(subtypes)))
+}
+
+// LangString is a name in a language, like the venue and operator names.
+type LangString struct {
+	// Lang is the ISO-639 language code.
+	Lang string
+	Name string
+}
+
+// NAIRealm is a realm from the NAI Realm list, and the EAP methods (by EAP
+// type number) it accepts.
+type NAIRealm struct {
+	// Realms usually has one entry; an entry may list several separated by ';'.
+	Realms     []string
+	EAPMethods []int
+}
+
+// PLMN is a cellular network from the 3GPP Cellular Network list.
+type PLMN struct {
+	MCC string
+	MNC string
+}
+
+// ANQPInfo is the ANQP information wpa_supplicant has cached for a BSS.
+// Elements that weren't received are empty.
+type ANQPInfo struct {
+	VenueGroup           int
+	VenueType            int
+	VenueName            []LangString
+	RoamingConsortium    []string
+	NAIRealms            []NAIRealm
+	Cellular             []PLMN
+	OperatorFriendlyName []LangString
+}
+
+var errShortANQP = errors.New("truncated")
+
+// parseLangStrings parses a list of length, language code, name duples.
+func parseLangStrings(b []byte) ([]LangString, error) {
+	var names []LangString
+	for len(b) > 0 {
+		n := int(b[0])
+		if n < 3 || len(b) < 1+n {
+			return nil, errShortANQP
+		}
+		names = append(names, LangString{
+			Lang: strings.TrimRight(string(b[1:4]), "\x00"),
+			Name: string(b[4 : 1+n]),
+		})
+		b = b[1+n:]
+	}
+	return names, nil
+}
+
+func parseRoamingConsortium(b []byte) ([]string, error) {
+	var ois []string
+	for len(b) > 0 {
+		n := int(b[0])
+		if len(b) < 1+n {
+			return nil, errShortANQP
+		}
+		ois = append(ois, hex.EncodeToString(b[1:1+n]))
+		b = b[1+n:]
+	}
+	return ois, nil
+}
+
+func le16(b []byte) int {
+	return int(b[0]) | int(b[1])<<8
+}
+
+func parseNAIRealms(b []byte) ([]NAIRealm, error) {
+	if len(b) < 2 {
+		return nil, errShortANQP
+	}
+	count := le16(b)
+	b = b[2:]
+	var realms []NAIRealm
+	for i := 0; i < count; i++ {
+		if len(b) < 2 || len(b) < 2+le16(b) {
+			return nil, errShortANQP
+		}
+		data := b[2 : 2+le16(b)]
+		b = b[2+le16(b):]
+
+		// encoding, realm length, realm, EAP method count
+		if len(data) < 2 || len(data) < 3+int(data[1]) {
+			return nil, errShortANQP
+		}
+		realm := NAIRealm{Realms: strings.Split(string(data[2:2+int(data[1])]), ";")}
+		data = data[2+int(data[1]):]
+		methods := int(data[0])
+		data = data[1:]
+		for j := 0; j < methods; j++ {
+			if len(data) < 2 || len(data) < 1+int(data[0]) {
+				return nil, errShortANQP
+			}
+			realm.EAPMethods = append(realm.EAPMethods, int(data[1]))
+			data = data[1+int(data[0]):]
+		}
+		realms = append(realms, realm)
+	}
+	return realms, nil
+}
+
+// bcdDigits decodes PLMN BCD nibbles. 0xf is padding.
+func bcdDigits(nibbles ...byte) string {
+	s := ""
+	for _, d := range nibbles {
+		if d <= 9 {
+			s += strconv.Itoa(int(d))
+		}
+	}
+	return s
+}
+
+func parse3GPPCellular(b []byte) ([]PLMN, error) {
+	// GUD, UDHL, then IEIs.
+	if len(b) < 2 || len(b) < 2+int(b[1]) {
+		return nil, errShortANQP
+	}
+	b = b[2 : 2+int(b[1])]
+	var plmns []PLMN
+	for len(b) >= 2 {
+		iei, n := b[0], int(b[1])
+		if len(b) < 2+n {
+			return nil, errShortANQP
+		}
+		body := b[2 : 2+n]
+		b = b[2+n:]
+		if iei != 0 || len(body) < 1 {
+			continue
+		}
+		count := int(body[0])
+		body = body[1:]
+		if len(body) < 3*count {
+			return nil, errShortANQP
+		}
+		for i := 0; i < count; i++ {
+			p := body[3*i : 3*i+3]
+			mcc := bcdDigits(p[0]&0xf, p[0]>>4, p[1]&0xf)
+			mnc := bcdDigits(p[2]&0xf, p[2]>>4, p[1]>>4)
+			plmns = append(plmns, PLMN{MCC: mcc, MNC: mnc})
+		}
+	}
+	return plmns, nil
+}
+
+// ANQPResults reads the ANQP elements cached for bssid from the BSS table.
+func (c *WPASupplicantCtrl) ANQPResults(bssid MAC) (*ANQPInfo, error) {
+	rsp, err := c.ctrl.FailCommand(fmt.Sprintf("BSS %s", bssid))
+	if err != nil {
+		return nil, err
+	}
+	kv := parseKeyValues(rsp)
+	elem := func(name string) ([]byte, error) {
+		b, err := hex.DecodeString(kv[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return b, nil
+	}
+	info := &ANQPInfo{}
+
+	b, err := elem("anqp_venue_name")
+	if err != nil {
+		return nil, err
+	}
+	if len(b) >= 2 {
+		info.VenueGroup, info.VenueType = int(b[0]), int(b[1])
+		if info.VenueName, err = parseLangStrings(b[2:]); err != nil {
+			return nil, fmt.Errorf("anqp_venue_name: %v", err)
+		}
+	}
+	if b, err = elem("anqp_roaming_consortium"); err != nil {
+		return nil, err
+	}
+	if info.RoamingConsortium, err = parseRoamingConsortium(b); err != nil {
+		return nil, fmt.Errorf("anqp_roaming_consortium: %v", err)
+	}
+	if b, err = elem("anqp_nai_realm"); err != nil {
+		return nil, err
+	}
+	if len(b) > 0 {
+		if info.NAIRealms, err = parseNAIRealms(b); err != nil {
+			return nil, fmt.Errorf("anqp_nai_realm: %v", err)
+		}
+	}
+	if b, err = elem("anqp_3gpp"); err != nil {
+		return nil, err
+	}
+	if len(b) > 0 {
+		if info.Cellular, err = parse3GPPCellular(b); err != nil {
+			return nil, fmt.Errorf("anqp_3gpp: %v", err)
+		}
+	}
+	if b, err = elem("hs20_operator_friendly_name"); err != nil {
+		return nil, err
+	}
+	if info.OperatorFriendlyName, err = parseLangStrings(b); err != nil {
+		return nil, fmt.Errorf("hs20_operator_friendly_name: %v", err)
+	}
+	return info, nil
+}
+
+// Cred is an interworking credential. Empty fields are not set.
+type Cred struct {
+	Realm    string
+	Username string
+	Password string
+	// Domain is the home service provider FQDN.
+	Domain string
+	// RoamingConsortium is a hex OI, e.g. "001bc50460".
+	RoamingConsortium string
+	// IMSI is of the form <MCC><MNC>-<MSIN>, e.g. "310026-000000000", for SIM
+	// based credentials.
+	IMSI string
+	// Milenage is "<Ki>:<OPc>:<SQN>" for SIM credentials without a SIM card.
+	Milenage string
+	EAP      string
+	CACert   string
+	Priority int
+}
+
+func (cr *Cred) vars() []NetworkVar {
+	var vars []NetworkVar
+	add := func(name, value string) {
+		if value != "" {
+			vars = append(vars, NetworkVar{name, QuoteString(value)})
+		}
+	}
+	add("realm", cr.Realm)
+	add("username", cr.Username)
+	add("password", cr.Password)
+	add("domain", cr.Domain)
+	if cr.RoamingConsortium != "" {
+		vars = append(vars, NetworkVar{"roaming_consortium", cr.RoamingConsortium})
+	}
+	add("imsi", cr.IMSI)
+	add("milenage", cr.Milenage)
+	if cr.EAP != "" {
+		vars = append(vars, NetworkVar{"eap", cr.EAP})
+	}
+	add("ca_cert", cr.CACert)
+	if cr.Priority != 0 {
+		vars = append(vars, NetworkVar{"priority", strconv.Itoa(cr.Priority)})
+	}
+	return vars
+}
+
+// AddCred adds a credential and returns its id. If setting any field fails the
+// credential is removed again.
+func (c *WPASupplicantCtrl) AddCred(cr *Cred) (string, error) {
+	if cr.Realm == "" && cr.IMSI == "" && cr.RoamingConsortium == "" {
+		return "", errors.New("credential needs a realm, imsi or roaming consortium")
+	}
+	rsp, err := c.ctrl.FailCommand("ADD_CRED")
+	if err != nil {
+		return "", err
+	}
+	id := strings.TrimSpace(rsp)
+	for _, v := range cr.vars() {
+		if err := c.SetCred(id, v.Name, v.Value); err != nil {
+			c.RemoveCred(id)
+			return "", fmt.Errorf("set %s: %v", v.Name, err)
+		}
+	}
+	return id, nil
+}
+
+// SetCred sets a raw credential field. Strings must be quoted, see QuoteString.
+func (c *WPASupplicantCtrl) SetCred(id, name, value string) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("SET_CRED %s %s %s", id, name, value))
+}
+
+// RemoveCred removes credential id, or every credential for "all".
+func (c *WPASupplicantCtrl) RemoveCred(id string) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("REMOVE_CRED %s", id))
+}
+
+// CredEntry is a row of LIST_CREDS.
+type CredEntry struct {
+	ID       string
+	Realm    string
+	Username string
+	Domain   string
+	IMSI     string
+}
+
+func (c *WPASupplicantCtrl) ListCreds() ([]CredEntry, error) {
+	rsp, err := c.ctrl.FailCommand("LIST_CREDS")
+	if err != nil {
+		return nil, err
+	}
+	var creds []CredEntry
+	lines := strings.Split(rsp, "\n")
+	// The first line is the header.
+	for _, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
+		f := strings.Split(line, "\t")
+		for len(f) < 5 {
+			f = append(f, "")
+		}
+		creds = append(creds, CredEntry{ID: f[0], Realm: f[1], Username: f[2], Domain: f[3], IMSI: f[4]})
+	}
+	return creds, nil
+}
+
+// OnInterworkingAPEvent is INTERWORKING-AP, sent by InterworkingSelect for an
+// AP a credential matches. Type is home, roaming or unknown.
+type OnInterworkingAPEvent struct {
+	baseEvent
+	BSSID    MAC
+	Type     string
+	CredID   string
+	Priority int
+}
+
+func NewOnInterworkingAPEvent(msg string) *OnInterworkingAPEvent {
+	f := parseEventFields(msg)
+	return &OnInterworkingAPEvent{
+		baseEvent: baseEvent{msg},
+		BSSID:     firstMAC(msg),
+		Type:      f["type"],
+		CredID:    f["id"],
+		Priority:  atoi(f["priority"]),
+	}
+}
+
+// OnRxANQPEvent is RX-ANQP, or RX-HS20-ANQP when HS20 is set, sent for each
+// ANQP element received. Info is the element name, e.g. "Venue Name".
+type OnRxANQPEvent struct {
+	baseEvent
+	BSSID MAC
+	HS20  bool
+	Info  string
+}
+
+func NewOnRxANQPEvent(msg string) *OnRxANQPEvent {
+	evt := &OnRxANQPEvent{
+		baseEvent: baseEvent{msg},
+		BSSID:     firstMAC(msg),
+		HS20:      strings.HasPrefix(msg, "RX-HS20-ANQP"),
+	}
+	if f := strings.SplitN(msg, " ", 3); len(f) == 3 {
+		evt.Info = f[2]
+	}
+	return evt
+}
+
+// OnANQPQueryDoneEvent is ANQP-QUERY-DONE. Result is SUCCESS or FAILURE.
+type OnANQPQueryDoneEvent struct {
+	baseEvent
+	Addr   MAC
+	Result string
+}
+
+func NewOnANQPQueryDoneEvent(msg string) *OnANQPQueryDoneEvent {
+	f := parseEventFields(msg)
+	addr, _ := ParseMAC(f["addr"])
+	return &OnANQPQueryDoneEvent{baseEvent: baseEvent{msg}, Addr: addr, Result: f["result"]}
+}
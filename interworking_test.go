@@ -0,0 +1,121 @@
+package wpa
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+func TestANQP(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+	bssid := MustParseMAC(wpatest.InterworkingBSSID)
+
+	if err := ctrl.ANQPGet(bssid, ANQPVenueName, ANQPNAIRealm, ANQP3GPPCellular); err != nil {
+		t.Fatal(err)
+	}
+	rx, ok := nextSupplicantEvent(t, ctrl).(*OnRxANQPEvent)
+	if !ok || rx.BSSID != bssid || rx.HS20 || rx.Info != "Venue Name" {
+		t.Fatalf("wrong event %+v", rx)
+	}
+	done, ok := nextSupplicantEvent(t, ctrl).(*OnANQPQueryDoneEvent)
+	if !ok || done.Addr != bssid || done.Result != "SUCCESS" {
+		t.Fatalf("wrong event %+v", done)
+	}
+	mock.Expect("HS20_ANQP_GET 02:00:00:00:05:00 3,4", "OK")
+	if err := ctrl.HS20ANQPGet(bssid, HS20OperatorFriendlyName, HS20WANMetrics); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.ANQPGet(bssid); err == nil {
+		t.Fatal("expected error without info IDs")
+	}
+
+	info, err := ctrl.ANQPResults(bssid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &ANQPInfo{
+		VenueGroup: 2,
+		VenueType:  8,
+		VenueName: []LangString{
+			{Lang: "eng", Name: "Example Airport"},
+			{Lang: "fra", Name: "Aeroport Exemple"},
+		},
+		RoamingConsortium: []string{"506f9a", "001bc50460"},
+		NAIRealms: []NAIRealm{
+			{Realms: []string{"example.com"}, EAPMethods: []int{21, 13}},
+			{Realms: []string{"mail.example.com", "corp.example.com"}, EAPMethods: []int{25}},
+		},
+		Cellular:             []PLMN{{MCC: "310", MNC: "026"}, {MCC: "234", MNC: "15"}},
+		OperatorFriendlyName: []LangString{{Lang: "eng", Name: "Example Operator"}},
+	}
+	if !reflect.DeepEqual(info, expect) {
+		t.Fatalf("wrong info\n%+v\n%+v", info, expect)
+	}
+
+	mock.Expect("BSS 02:00:00:00:05:00", "bssid=02:00:00:00:05:00\nanqp_nai_realm=0100ff00")
+	if _, err := ctrl.ANQPResults(bssid); err == nil {
+		t.Fatal("expected truncated NAI realm error")
+	}
+}
+
+func TestCreds(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ctrl.AddCred(&Cred{Username: "alice"}); err == nil {
+		t.Fatal("expected error without realm")
+	}
+	id, err := ctrl.AddCred(&Cred{
+		Realm:    "example.com",
+		Username: "alice",
+		Password: "secret",
+		Domain:   "example.com",
+		EAP:      "TTLS",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.Expect("ADD_CRED", "1")
+	mock.Expect(`SET_CRED 1 imsi "310026-000000000"`, "OK")
+	mock.Expect(`SET_CRED 1 milenage "90dca4eda45b53cf0f12d7c9c3bc6a89:cb9cccc4b9258e6dca4760379fb82581:000000000123"`, "FAIL")
+	mock.Expect("REMOVE_CRED 1", "OK")
+	_, err = ctrl.AddCred(&Cred{
+		IMSI:     "310026-000000000",
+		Milenage: "90dca4eda45b53cf0f12d7c9c3bc6a89:cb9cccc4b9258e6dca4760379fb82581:000000000123",
+	})
+	if err == nil {
+		t.Fatal("expected set error")
+	}
+
+	creds, err := ctrl.ListCreds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(creds) != 1 || creds[0] != (CredEntry{ID: id, Realm: "example.com", Username: "alice", Domain: "example.com"}) {
+		t.Fatalf("wrong creds %+v", creds)
+	}
+
+	if err := ctrl.InterworkingSelect(false); err != nil {
+		t.Fatal(err)
+	}
+	ap, ok := nextSupplicantEvent(t, ctrl).(*OnInterworkingAPEvent)
+	if !ok || ap.BSSID != MustParseMAC(wpatest.InterworkingBSSID) || ap.Type != "home" || ap.CredID != id {
+		t.Fatalf("wrong event %+v", ap)
+	}
+	if err := ctrl.InterworkingConnect(ap.BSSID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ctrl.RemoveCred(id); err != nil {
+		t.Fatal(err)
+	}
+	if creds, err = ctrl.ListCreds(); err != nil || len(creds) != 0 {
+		t.Fatal(creds, err)
+	}
+}
@@ -0,0 +1,68 @@
+package wpa
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// MAC is a 48-bit hardware address, as used for BSSIDs and station addresses.
+// It's comparable, so it can be used as a map key.
+type MAC [6]byte
+
+// ParseMAC parses an address in any format accepted by net.ParseMAC,
+// as long as it's 48 bits.
+func ParseMAC(s string) (MAC, error) {
+	hw, err := net.ParseMAC(s)
+	if err != nil {
+		return MAC{}, err
+	}
+	if len(hw) != 6 {
+		return MAC{}, fmt.Errorf("not a 48-bit address: %s", s)
+	}
+	var m MAC
+	copy(m[:], hw)
+	return m, nil
+}
+
+// MustParseMAC is like ParseMAC but panics on error. It's meant for constants.
+func MustParseMAC(s string) MAC {
+	m, err := ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// String formats the address the way wpa_supplicant and hostapd do.
+func (m MAC) String() string {
+	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x", m[0], m[1], m[2], m[3], m[4], m[5])
+}
+
+func (m MAC) IsZero() bool {
+	return m == MAC{}
+}
+
+func (m MAC) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *MAC) UnmarshalText(b []byte) error {
+	p, err := ParseMAC(string(b))
+	if err != nil {
+		return err
+	}
+	*m = p
+	return nil
+}
+
+// firstMAC parses the first field of an event, after the event name, as an
+// address. Many events are of the form "EVENT-NAME <addr> key=value...".
+func firstMAC(msg string) MAC {
+	f := strings.Fields(msg)
+	if len(f) < 2 {
+		return MAC{}
+	}
+	m, _ := ParseMAC(f[1])
+	return m
+}
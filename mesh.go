@@ -0,0 +1,114 @@
+package wpa
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SetMesh makes the config an open 802.11s mesh (mode=5) on freq, in MHz.
+// Use SetMeshSAE for a secure mesh.
+func (n *NetworkConfig) SetMesh(ssid string, freq int) {
+	n.SetSSID(ssid)
+	n.Set("mode", "5")
+	n.Set("frequency", strconv.Itoa(freq))
+	n.SetKeyMgmt("NONE")
+}
+
+// SetMeshSAE secures the mesh with SAE. Every mesh point must use the same
+// passphrase; PMF protects the peering management frames.
+func (n *NetworkConfig) SetMeshSAE(passphrase string) error {
+	if l := len(passphrase); l < 8 || l > 63 {
+		return fmt.Errorf("passphrase must be 8 to 63 characters, not %d", l)
+	}
+	n.SetKeyMgmt("SAE")
+	n.SetString("psk", passphrase)
+	n.SetIEEE80211W(PMFRequired)
+	return nil
+}
+
+// SetMeshForwarding enables or disables HWMP path selection and forwarding
+// between mesh peers.
+func (n *NetworkConfig) SetMeshForwarding(fwding bool) {
+	n.Set("mesh_fwding", boolParam(fwding))
+}
+
+// MeshInterfaceAdd creates a separate mesh interface and returns its name. An
+// empty ifname lets wpa_supplicant pick one.
+func (c *WPASupplicantCtrl) MeshInterfaceAdd(ifname string) (string, error) {
+	cmd := "MESH_INTERFACE_ADD"
+	if ifname != "" {
+		cmd += " ifname=" + ifname
+	}
+	rsp, err := c.ctrl.FailCommand(cmd)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(rsp), nil
+}
+
+// MeshGroupAdd joins or starts the mesh configured as network id. It's reported
+// with MESH-GROUP-STARTED.
+func (c *WPASupplicantCtrl) MeshGroupAdd(network string) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("MESH_GROUP_ADD %s", network))
+}
+
+// MeshGroupRemove leaves the mesh on interface ifname.
+func (c *WPASupplicantCtrl) MeshGroupRemove(ifname string) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("MESH_GROUP_REMOVE %s", ifname))
+}
+
+// MeshPeerAdd opens a peering with addr, which must be a mesh peer candidate
+// already seen in beacons. A non-zero duration (in seconds) limits how long the
+// peer is kept when it isn't active.
+func (c *WPASupplicantCtrl) MeshPeerAdd(addr MAC, duration int) error {
+	cmd := fmt.Sprintf("MESH_PEER_ADD %s", addr)
+	if duration != 0 {
+		cmd += fmt.Sprintf(" duration=%d", duration)
+	}
+	return c.ctrl.OkCommand(cmd)
+}
+
+// MeshPeerRemove closes the peering with addr.
+func (c *WPASupplicantCtrl) MeshPeerRemove(addr MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("MESH_PEER_REMOVE %s", addr))
+}
+
+// OnMeshGroupStartedEvent is MESH-GROUP-STARTED. ID is the network id.
+type OnMeshGroupStartedEvent struct {
+	baseEvent
+	SSID string
+	ID   int
+}
+
+func NewOnMeshGroupStartedEvent(msg string) *OnMeshGroupStartedEvent {
+	f := parseEventFields(msg)
+	return &OnMeshGroupStartedEvent{baseEvent: baseEvent{msg}, SSID: f["ssid"], ID: atoi(f["id"])}
+}
+
+// OnMeshGroupRemovedEvent is MESH-GROUP-REMOVED.
+type OnMeshGroupRemovedEvent struct {
+	baseEvent
+	Ifname string
+}
+
+func NewOnMeshGroupRemovedEvent(msg string) *OnMeshGroupRemovedEvent {
+	evt := &OnMeshGroupRemovedEvent{baseEvent: baseEvent{msg}}
+	if f := strings.Fields(msg); len(f) > 1 {
+		evt.Ifname = f[1]
+	}
+	return evt
+}
+
+// OnMeshPeerConnectedEvent is MESH-PEER-CONNECTED, sent when a peering is
+// established.
+type OnMeshPeerConnectedEvent struct {
+	baseEvent
+	Addr MAC
+}
+
+// OnMeshPeerDisconnectedEvent is MESH-PEER-DISCONNECTED.
+type OnMeshPeerDisconnectedEvent struct {
+	baseEvent
+	Addr MAC
+}
@@ -0,0 +1,98 @@
+package wpa
+
+import (
+	"testing"
+
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+func TestMeshConfig(t *testing.T) {
+	cfg := NewNetworkConfig()
+	cfg.SetMesh("backhaul", 5180)
+	if err := cfg.SetMeshSAE("short"); err == nil {
+		t.Fatal("expected passphrase error")
+	}
+	if err := cfg.SetMeshSAE("mesh secret"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.SetMeshForwarding(false)
+	expect := []NetworkVar{
+		{"ssid", `"backhaul"`},
+		{"mode", "5"},
+		{"frequency", "5180"},
+		{"key_mgmt", "SAE"},
+		{"psk", `"mesh secret"`},
+		{"ieee80211w", "2"},
+		{"mesh_fwding", "0"},
+	}
+	vars := cfg.Vars()
+	if len(vars) != len(expect) {
+		t.Fatalf("wrong vars %v", vars)
+	}
+	for i := range expect {
+		if vars[i] != expect[i] {
+			t.Fatalf("var %d: expected %v, got %v", i, expect[i], vars[i])
+		}
+	}
+}
+
+func TestMeshGroup(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	ifname, err := ctrl.MeshInterfaceAdd("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ifname != wpatest.MeshIfname {
+		t.Fatal("wrong ifname", ifname)
+	}
+
+	id, err := ctrl.AddNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.SetSSID(id, "backhaul"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.MeshGroupAdd(id); err != nil {
+		t.Fatal(err)
+	}
+	started, ok := nextSupplicantEvent(t, ctrl).(*OnMeshGroupStartedEvent)
+	if !ok || started.SSID != "backhaul" || started.ID != 0 {
+		t.Fatalf("wrong event %+v", started)
+	}
+
+	peer := MustParseMAC("02:00:00:00:07:00")
+	mock.Expect("MESH_PEER_ADD 02:00:00:00:07:00 duration=60", "OK")
+	if err := ctrl.MeshPeerAdd(peer, 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.MeshPeerAdd(peer, 0); err != nil {
+		t.Fatal(err)
+	}
+	connected, ok := nextSupplicantEvent(t, ctrl).(*OnMeshPeerConnectedEvent)
+	if !ok || connected.Addr != peer {
+		t.Fatalf("wrong event %+v", connected)
+	}
+	if err := ctrl.MeshPeerRemove(peer); err != nil {
+		t.Fatal(err)
+	}
+	disconnected, ok := nextSupplicantEvent(t, ctrl).(*OnMeshPeerDisconnectedEvent)
+	if !ok || disconnected.Addr != peer {
+		t.Fatalf("wrong event %+v", disconnected)
+	}
+
+	if err := ctrl.MeshGroupRemove(ifname); err != nil {
+		t.Fatal(err)
+	}
+	removed, ok := nextSupplicantEvent(t, ctrl).(*OnMeshGroupRemovedEvent)
+	if !ok || removed.Ifname != wpatest.MeshIfname {
+		t.Fatalf("wrong event %+v", removed)
+	}
+	if err := ctrl.MeshPeerAdd(peer, 0); err == nil {
+		t.Fatal("expected peer add without a mesh to fail")
+	}
+}
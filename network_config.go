@@ -0,0 +1,161 @@
+package wpa
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NetworkVar is a single network block variable.
+// Value is stored the way wpa_supplicant expects it, so string values keep
+// their surrounding quotes.
+type NetworkVar struct {
+	Name  string
+	Value string
+}
+
+// NetworkConfig holds the variables of a network block, in the order they were set.
+// The same config can be applied with SET_NETWORK (see ConfigureNetwork) or written
+// to a network={} block of wpa_supplicant.conf.
+type NetworkConfig struct {
+	vars []NetworkVar
+}
+
+func NewNetworkConfig() *NetworkConfig {
+	return &NetworkConfig{}
+}
+
+// Set stores a raw value for name, replacing any previous value.
+// New variables are appended, so the order of Set calls is preserved.
+func (n *NetworkConfig) Set(name, value string) {
+	for i := range n.vars {
+		if n.vars[i].Name == name {
+			n.vars[i].Value = value
+			return
+		}
+	}
+	n.vars = append(n.vars, NetworkVar{Name: name, Value: value})
+}
+
+// SetString stores a string value for name, quoting it as needed.
+func (n *NetworkConfig) SetString(name, value string) {
+	n.Set(name, QuoteString(value))
+}
+
+// Get returns the raw value for name.
+func (n *NetworkConfig) Get(name string) (string, bool) {
+	for _, v := range n.vars {
+		if v.Name == name {
+			return v.Value, true
+		}
+	}
+	return "", false
+}
+
+// GetString returns the value for name with any quoting or hex encoding removed.
+func (n *NetworkConfig) GetString(name string) (string, bool) {
+	v, ok := n.Get(name)
+	if !ok {
+		return "", false
+	}
+	return UnquoteString(v), true
+}
+
+// Unset removes name from the config.
+func (n *NetworkConfig) Unset(name string) {
+	for i := range n.vars {
+		if n.vars[i].Name == name {
+			n.vars = append(n.vars[:i], n.vars[i+1:]...)
+			return
+		}
+	}
+}
+
+// Vars returns a copy of the variables, in order.
+func (n *NetworkConfig) Vars() []NetworkVar {
+	return append([]NetworkVar(nil), n.vars...)
+}
+
+func (n *NetworkConfig) SSID() string {
+	s, _ := n.GetString("ssid")
+	return s
+}
+
+func (n *NetworkConfig) SetSSID(ssid string) {
+	n.SetString("ssid", ssid)
+}
+
+// SetPSK sets a WPA passphrase. A 64 character hex string is treated as a raw PSK
+// and is stored without quotes.
+func (n *NetworkConfig) SetPSK(psk string) {
+	if len(psk) == 64 && isHex(psk) {
+		n.Set("psk", psk)
+		return
+	}
+	n.SetString("psk", psk)
+}
+
+func (n *NetworkConfig) KeyMgmt() []string {
+	v, _ := n.Get("key_mgmt")
+	return strings.Fields(v)
+}
+
+func (n *NetworkConfig) SetKeyMgmt(keyMgmt ...string) {
+	n.Set("key_mgmt", strings.Join(keyMgmt, " "))
+}
+
+func (n *NetworkConfig) Priority() int {
+	v, _ := n.Get("priority")
+	p, _ := strconv.Atoi(v)
+	return p
+}
+
+func (n *NetworkConfig) SetPriority(p int) {
+	n.Set("priority", strconv.Itoa(p))
+}
+
+// QuoteString formats s as a wpa_supplicant string value.
+// Printable strings are quoted, anything else is hex encoded.
+func QuoteString(s string) string {
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return hex.EncodeToString([]byte(s))
+		}
+	}
+	return fmt.Sprintf("\"%s\"", s)
+}
+
+// UnquoteString reverses QuoteString. Values that are neither quoted nor valid hex
+// are returned unchanged.
+func UnquoteString(v string) string {
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		return v[1 : len(v)-1]
+	}
+	if strings.HasPrefix(v, "P\"") && strings.HasSuffix(v, "\"") {
+		if s, err := strconv.Unquote(v[1:]); err == nil {
+			return s
+		}
+		return v[2 : len(v)-1]
+	}
+	if b, err := hex.DecodeString(v); err == nil && len(v) > 0 {
+		return string(b)
+	}
+	return v
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// ConfigureNetwork applies every variable in cfg to network id via SET_NETWORK.
+func (c *WPASupplicantCtrl) ConfigureNetwork(network string, cfg *NetworkConfig) error {
+	for _, v := range cfg.vars {
+		err := c.ctrl.OkCommand(fmt.Sprintf("SET_NETWORK %s %s %s", network, v.Name, v.Value))
+		if err != nil {
+			return fmt.Errorf("set %s: %v", v.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+package wpa
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// P2PFind starts P2P device discovery. A non-zero timeout (in seconds) stops
+// it automatically. Found devices are reported with P2P-DEVICE-FOUND.
+func (c *WPASupplicantCtrl) P2PFind(timeout int) error {
+	if timeout == 0 {
+		return c.ctrl.OkCommand("P2P_FIND")
+	}
+	return c.ctrl.OkCommand(fmt.Sprintf("P2P_FIND %d", timeout))
+}
+
+func (c *WPASupplicantCtrl) P2PStopFind() error {
+	return c.ctrl.OkCommand("P2P_STOP_FIND")
+}
+
+// P2PConnectParams are the P2P_CONNECT parameters.
+type P2PConnectParams struct {
+	// WPS is "pbc", "pin" to have wpa_supplicant generate a PIN, or the PIN.
+	WPS string
+	// PINMode is "display" if this device shows the PIN, "keypad" if it's
+	// entered here. Empty uses the default for the WPS method.
+	PINMode    string
+	Persistent bool
+	// Join joins an existing group with the peer as GO instead of negotiating.
+	Join bool
+	// Auth authorizes the peer to connect without starting negotiation here.
+	Auth bool
+	// GOIntent is 0 to 15, how much this device wants to be GO. Nil uses the
+	// configured p2p_go_intent.
+	GOIntent *int
+	// Freq forces the operating frequency in MHz.
+	Freq int
+}
+
+// P2PConnect starts group formation with peer. With WPS set to "pin", the
+// generated PIN is returned; otherwise the result is empty.
+func (c *WPASupplicantCtrl) P2PConnect(peer MAC, p P2PConnectParams) (string, error) {
+	switch p.WPS {
+	case "pbc", "pin":
+	case "":
+		return "", errors.New("WPS method is required")
+	default:
+		if err := ValidateWPSPIN(p.WPS); err != nil {
+			return "", err
+		}
+	}
+	if p.Join && p.Auth {
+		return "", errors.New("join and auth are exclusive")
+	}
+	args := []string{"P2P_CONNECT", peer.String(), p.WPS}
+	if p.PINMode != "" {
+		args = append(args, p.PINMode)
+	}
+	if p.Persistent {
+		args = append(args, "persistent")
+	}
+	if p.Join {
+		args = append(args, "join")
+	}
+	if p.Auth {
+		args = append(args, "auth")
+	}
+	if p.GOIntent != nil {
+		if *p.GOIntent < 0 || *p.GOIntent > 15 {
+			return "", fmt.Errorf("go_intent %d is not 0 to 15", *p.GOIntent)
+		}
+		args = append(args, fmt.Sprintf("go_intent=%d", *p.GOIntent))
+	}
+	if p.Freq != 0 {
+		args = append(args, fmt.Sprintf("freq=%d", p.Freq))
+	}
+	rsp, err := c.ctrl.FailCommand(strings.Join(args, " "))
+	if err != nil {
+		return "", err
+	}
+	if rsp == "OK" {
+		return "", nil
+	}
+	return rsp, nil
+}
+
+// P2PGroupAdd starts an autonomous group with this device as GO. A zero freq
+// lets wpa_supplicant pick. The group interface is reported with
+// P2P-GROUP-STARTED.
+func (c *WPASupplicantCtrl) P2PGroupAdd(freq int, persistent bool) error {
+	cmd := "P2P_GROUP_ADD"
+	if persistent {
+		cmd += " persistent"
+	}
+	if freq != 0 {
+		cmd += fmt.Sprintf(" freq=%d", freq)
+	}
+	return c.ctrl.OkCommand(cmd)
+}
+
+// P2PGroupRemove terminates the group on group interface ifname.
+func (c *WPASupplicantCtrl) P2PGroupRemove(ifname string) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("P2P_GROUP_REMOVE %s", ifname))
+}
+
+// P2PInvite invites peer to the running group on group interface ifname.
+func (c *WPASupplicantCtrl) P2PInvite(ifname string, peer MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("P2P_INVITE group=%s peer=%s", ifname, peer))
+}
+
+// P2PInvitePersistent invites peer to re-form the persistent group stored as
+// network id.
+func (c *WPASupplicantCtrl) P2PInvitePersistent(network string, peer MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("P2P_INVITE persistent=%s peer=%s", network, peer))
+}
+
+// P2PPeers lists the P2P device addresses of known peers.
+func (c *WPASupplicantCtrl) P2PPeers() ([]MAC, error) {
+	rsp, err := c.ctrl.FailCommand("P2P_PEERS")
+	if err != nil {
+		return nil, err
+	}
+	var peers []MAC
+	for _, line := range strings.Split(rsp, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		addr, err := ParseMAC(line)
+		if err != nil {
+			return nil, fmt.Errorf("bad peer %q: %v", line, err)
+		}
+		peers = append(peers, addr)
+	}
+	return peers, nil
+}
+
+// P2PPeer is the response to P2P_PEER.
+type P2PPeer struct {
+	Addr MAC
+	// PriDevType is the WPS primary device type, e.g. 1-0050F204-1.
+	PriDevType    string
+	DeviceName    string
+	Manufacturer  string
+	ModelName     string
+	ModelNumber   string
+	SerialNumber  string
+	ConfigMethods int
+	DevCapab      int
+	GroupCapab    int
+	Level         int
+	InterfaceAddr MAC
+	OperFreq      int
+	Raw           map[string]string
+}
+
+// parseHexInt parses the 0x prefixed fields of P2P responses and events.
+func parseHexInt(s string) int {
+	n, _ := strconv.ParseInt(s, 0, 64)
+	return int(n)
+}
+
+func (c *WPASupplicantCtrl) P2PPeer(addr MAC) (*P2PPeer, error) {
+	rsp, err := c.ctrl.FailCommand(fmt.Sprintf("P2P_PEER %s", addr))
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.SplitN(rsp, "\n", 2)
+	peerAddr, err := ParseMAC(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return nil, fmt.Errorf("bad peer response: %v", err)
+	}
+	kv := map[string]string{}
+	if len(lines) > 1 {
+		kv = parseKeyValues(lines[1])
+	}
+	ifAddr, _ := ParseMAC(kv["interface_addr"])
+	return &P2PPeer{
+		Addr:          peerAddr,
+		PriDevType:    kv["pri_dev_type"],
+		DeviceName:    kv["device_name"],
+		Manufacturer:  kv["manufacturer"],
+		ModelName:     kv["model_name"],
+		ModelNumber:   kv["model_number"],
+		SerialNumber:  kv["serial_number"],
+		ConfigMethods: parseHexInt(kv["config_methods"]),
+		DevCapab:      parseHexInt(kv["dev_capab"]),
+		GroupCapab:    parseHexInt(kv["group_capab"]),
+		Level:         atoi(kv["level"]),
+		InterfaceAddr: ifAddr,
+		OperFreq:      atoi(kv["oper_freq"]),
+		Raw:           kv,
+	}, nil
+}
+
+// P2PGroupDialer opens a connection to the control interface of a P2P group
+// interface, which wpa_supplicant creates next to the parent's.
+type P2PGroupDialer func(ifname string) (Ctrl, error)
+
+// P2PGroupCtrl returns a WPASupplicantCtrl for group interface ifname, as
+// reported by P2P-GROUP-STARTED. If c came from GlobalCtrl.Interface the group
+// shares the global connection and dial isn't used; otherwise dial opens the
+// group's own connection.
+func (c *WPASupplicantCtrl) P2PGroupCtrl(ifname string, dial P2PGroupDialer) (*WPASupplicantCtrl, error) {
+	if ic, ok := c.ctrl.(*ifaceCtrl); ok {
+		return ic.g.Interface(ifname), nil
+	}
+	if dial == nil {
+		return nil, errors.New("no dialer for group interface")
+	}
+	ctrl, err := dial(ifname)
+	if err != nil {
+		return nil, err
+	}
+	return NewWPASupplicantCtrl(ctrl, c.cmdTimeout), nil
+}
+
+// OnP2PDeviceFoundEvent is P2P-DEVICE-FOUND, sent during P2PFind.
+type OnP2PDeviceFoundEvent struct {
+	baseEvent
+	Addr          MAC
+	DevAddr       MAC
+	PriDevType    string
+	Name          string
+	ConfigMethods int
+	DevCapab      int
+	GroupCapab    int
+}
+
+func NewOnP2PDeviceFoundEvent(msg string) *OnP2PDeviceFoundEvent {
+	f := parseEventFields(msg)
+	devAddr, _ := ParseMAC(f["p2p_dev_addr"])
+	return &OnP2PDeviceFoundEvent{
+		baseEvent:     baseEvent{msg},
+		Addr:          firstMAC(msg),
+		DevAddr:       devAddr,
+		PriDevType:    f["pri_dev_type"],
+		Name:          f["name"],
+		ConfigMethods: parseHexInt(f["config_methods"]),
+		DevCapab:      parseHexInt(f["dev_capab"]),
+		GroupCapab:    parseHexInt(f["group_capab"]),
+	}
+}
+
+// OnP2PGoNegRequestEvent is P2P-GO-NEG-REQUEST, sent when a peer wants to form
+// a group. Accept it with P2PConnect.
+type OnP2PGoNegRequestEvent struct {
+	baseEvent
+	Addr        MAC
+	DevPasswdID int
+	GOIntent    int
+}
+
+func NewOnP2PGoNegRequestEvent(msg string) *OnP2PGoNegRequestEvent {
+	f := parseEventFields(msg)
+	return &OnP2PGoNegRequestEvent{
+		baseEvent:   baseEvent{msg},
+		Addr:        firstMAC(msg),
+		DevPasswdID: atoi(f["dev_passwd_id"]),
+		GOIntent:    atoi(f["go_intent"]),
+	}
+}
+
+// OnP2PGoNegSuccessEvent is P2P-GO-NEG-SUCCESS. Role is GO or client.
+type OnP2PGoNegSuccessEvent struct {
+	baseEvent
+	Role      string
+	Freq      int
+	PeerDev   MAC
+	PeerIface MAC
+	WPSMethod string
+}
+
+func NewOnP2PGoNegSuccessEvent(msg string) *OnP2PGoNegSuccessEvent {
+	f := parseEventFields(msg)
+	peerDev, _ := ParseMAC(f["peer_dev"])
+	peerIface, _ := ParseMAC(f["peer_iface"])
+	return &OnP2PGoNegSuccessEvent{
+		baseEvent: baseEvent{msg},
+		Role:      f["role"],
+		Freq:      atoi(f["freq"]),
+		PeerDev:   peerDev,
+		PeerIface: peerIface,
+		WPSMethod: f["wps_method"],
+	}
+}
+
+// OnP2PGoNegFailureEvent is P2P-GO-NEG-FAILURE. Status is the P2P status code.
+type OnP2PGoNegFailureEvent struct {
+	baseEvent
+	Status int
+}
+
+// OnP2PGroupStartedEvent is P2P-GROUP-STARTED. Ifname is the group interface;
+// use P2PGroupCtrl to control it.
+type OnP2PGroupStartedEvent struct {
+	baseEvent
+	Ifname string
+	GO     bool
+	SSID   string
+	Freq   int
+	// Passphrase is only reported to the GO.
+	Passphrase string
+	GODevAddr  MAC
+	Persistent bool
+}
+
+func NewOnP2PGroupStartedEvent(msg string) *OnP2PGroupStartedEvent {
+	evt := &OnP2PGroupStartedEvent{baseEvent: baseEvent{msg}}
+	fields := strings.Fields(msg)
+	if len(fields) > 2 {
+		evt.Ifname = fields[1]
+		evt.GO = fields[2] == "GO"
+	}
+	f := parseEventFields(msg)
+	evt.SSID = f["ssid"]
+	evt.Freq = atoi(f["freq"])
+	evt.Passphrase = f["passphrase"]
+	evt.GODevAddr, _ = ParseMAC(f["go_dev_addr"])
+	evt.Persistent = strings.HasSuffix(msg, "[PERSISTENT]")
+	return evt
+}
+
+// OnP2PGroupRemovedEvent is P2P-GROUP-REMOVED. Reason is e.g. REQUESTED,
+// FORMATION_FAILED, IDLE or UNAVAILABLE.
+type OnP2PGroupRemovedEvent struct {
+	baseEvent
+	Ifname string
+	GO     bool
+	Reason string
+}
+
+func NewOnP2PGroupRemovedEvent(msg string) *OnP2PGroupRemovedEvent {
+	evt := &OnP2PGroupRemovedEvent{baseEvent: baseEvent{msg}}
+	fields := strings.Fields(msg)
+	if len(fields) > 2 {
+		evt.Ifname = fields[1]
+		evt.GO = fields[2] == "GO"
+	}
+	evt.Reason = parseEventFields(msg)["reason"]
+	return evt
+}
+
+// OnP2PProvDiscEvent is any of the P2P-PROV-DISC-* provision discovery events.
+type OnP2PProvDiscEvent struct {
+	baseEvent
+	// Type is the part of the event name after P2P-PROV-DISC-: SHOW-PIN,
+	// ENTER-PIN, PBC-REQ, PBC-RESP or FAILURE.
+	Type string
+	Addr MAC
+	// PIN is set for SHOW-PIN, and is to be entered on the peer.
+	PIN string
+	// Status is set for FAILURE.
+	Status int
+}
+
+func NewOnP2PProvDiscEvent(msg string) *OnP2PProvDiscEvent {
+	fields := strings.Fields(msg)
+	f := parseEventFields(msg)
+	evt := &OnP2PProvDiscEvent{
+		baseEvent: baseEvent{msg},
+		Type:      strings.TrimPrefix(fields[0], "P2P-PROV-DISC-"),
+		Status:    atoi(f["status"]),
+	}
+	if evt.Type == "FAILURE" {
+		evt.Addr, _ = ParseMAC(f["p2p_dev_addr"])
+		return evt
+	}
+	evt.Addr = firstMAC(msg)
+	if evt.Type == "SHOW-PIN" && len(fields) > 2 {
+		evt.PIN = fields[2]
+	}
+	return evt
+}
+
+// parseP2PEvent parses P2P events. It returns nil for anything else.
+func parseP2PEvent(msg string) WPASupplicantEvent {
+	if !strings.HasPrefix(msg, "P2P-") {
+		return nil
+	}
+	switch {
+	case strings.HasPrefix(msg, "P2P-DEVICE-FOUND"):
+		return NewOnP2PDeviceFoundEvent(msg)
+	case strings.HasPrefix(msg, "P2P-GO-NEG-REQUEST"):
+		return NewOnP2PGoNegRequestEvent(msg)
+	case strings.HasPrefix(msg, "P2P-GO-NEG-SUCCESS"):
+		return NewOnP2PGoNegSuccessEvent(msg)
+	case strings.HasPrefix(msg, "P2P-GO-NEG-FAILURE"):
+		return &OnP2PGoNegFailureEvent{baseEvent: baseEvent{msg}, Status: atoi(parseEventFields(msg)["status"])}
+	case strings.HasPrefix(msg, "P2P-GROUP-STARTED"):
+		return NewOnP2PGroupStartedEvent(msg)
+	case strings.HasPrefix(msg, "P2P-GROUP-REMOVED"):
+		return NewOnP2PGroupRemovedEvent(msg)
+	case strings.HasPrefix(msg, "P2P-PROV-DISC-"):
+		return NewOnP2PProvDiscEvent(msg)
+	}
+	return nil
+}
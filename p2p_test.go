@@ -0,0 +1,191 @@
+package wpa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+func nextSupplicantEvent(t *testing.T, ctrl *WPASupplicantCtrl) WPASupplicantEvent {
+	select {
+	case evt := <-ctrl.Events():
+		return evt
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return nil
+}
+
+func TestP2PPeers(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+
+	mock.Expect("P2P_FIND 30", "OK")
+	if err := ctrl.P2PFind(30); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.P2PStopFind(); err != nil {
+		t.Fatal(err)
+	}
+
+	peers, err := ctrl.P2PPeers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 2 || peers[0] != MustParseMAC(wpatest.P2PPeers[0]) {
+		t.Fatal("wrong peers", peers)
+	}
+	peer, err := ctrl.P2PPeer(peers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peer.DeviceName != "Living Room TV" || peer.ConfigMethods != 0x188 || peer.DevCapab != 0x25 ||
+		peer.Level != -43 || peer.InterfaceAddr != MustParseMAC("02:00:00:00:02:01") {
+		t.Fatalf("wrong peer %+v", peer)
+	}
+	if _, err := ctrl.P2PPeer(MustParseMAC("02:00:00:00:09:00")); err == nil {
+		t.Fatal("expected unknown peer to fail")
+	}
+}
+
+func TestP2PConnect(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	peer := MustParseMAC(wpatest.P2PPeers[0])
+
+	pin, err := ctrl.P2PConnect(peer, P2PConnectParams{WPS: "pin", PINMode: "display"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin != wpatest.GeneratedWPSPIN {
+		t.Fatal("wrong pin", pin)
+	}
+
+	intent := 0
+	mock.Expect("P2P_CONNECT 02:00:00:00:02:00 pbc persistent join go_intent=0 freq=2437", "OK")
+	pin, err = ctrl.P2PConnect(peer, P2PConnectParams{WPS: "pbc", Persistent: true, Join: true, GOIntent: &intent, Freq: 2437})
+	if err != nil || pin != "" {
+		t.Fatal(pin, err)
+	}
+
+	for _, p := range []P2PConnectParams{
+		{},
+		{WPS: "12345678"},
+		{WPS: "pbc", Join: true, Auth: true},
+	} {
+		if _, err := ctrl.P2PConnect(peer, p); err == nil {
+			t.Fatalf("expected error for %+v", p)
+		}
+	}
+	intent = 16
+	if _, err := ctrl.P2PConnect(peer, P2PConnectParams{WPS: "pbc", GOIntent: &intent}); err == nil {
+		t.Fatal("expected go_intent error")
+	}
+
+	mock.Expect("P2P_INVITE persistent=3 peer=02:00:00:00:02:00", "OK")
+	if err := ctrl.P2PInvitePersistent("3", peer); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestP2PGroup(t *testing.T) {
+	_, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ctrl.P2PGroupAdd(0, false); err != nil {
+		t.Fatal(err)
+	}
+	started, ok := nextSupplicantEvent(t, ctrl).(*OnP2PGroupStartedEvent)
+	if !ok {
+		t.Fatal("expected group started")
+	}
+	if started.Ifname != wpatest.P2PGroupIfname || !started.GO || started.SSID != "DIRECT-mock" ||
+		started.Freq != 2412 || started.Passphrase != "mockpass" || started.Persistent {
+		t.Fatalf("wrong event %+v", started)
+	}
+
+	// The group interface has its own control socket.
+	dialed := ""
+	group, err := ctrl.P2PGroupCtrl(started.Ifname, func(ifname string) (Ctrl, error) {
+		dialed = ifname
+		_, c := NewWPATest(t)
+		return c, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dialed != wpatest.P2PGroupIfname {
+		t.Fatal("dialed", dialed)
+	}
+	if err := group.P2PInvite(started.Ifname, MustParseMAC(wpatest.P2PPeers[1])); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ctrl.P2PGroupCtrl(started.Ifname, nil); err == nil {
+		t.Fatal("expected error without dialer")
+	}
+
+	if err := ctrl.P2PGroupRemove(started.Ifname); err != nil {
+		t.Fatal(err)
+	}
+	removed, ok := nextSupplicantEvent(t, ctrl).(*OnP2PGroupRemovedEvent)
+	if !ok || removed.Ifname != wpatest.P2PGroupIfname || removed.Reason != "REQUESTED" {
+		t.Fatalf("wrong event %+v", removed)
+	}
+	if err := ctrl.P2PGroupRemove(started.Ifname); err == nil {
+		t.Fatal("expected removing twice to fail")
+	}
+}
+
+func TestP2PGroupGlobal(t *testing.T) {
+	mock, g := NewGlobalTest(t)
+	ctrl := g.Interface("wlan0")
+
+	group, err := ctrl.P2PGroupCtrl(wpatest.P2PGroupIfname, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.Expect("IFNAME=p2p-wlan0-0 P2P_GROUP_REMOVE p2p-wlan0-0", "OK")
+	if err := group.P2PGroupRemove(wpatest.P2PGroupIfname); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestP2PEvents(t *testing.T) {
+	found, ok := parseSupplicantEvent("P2P-DEVICE-FOUND 02:00:00:00:02:01 p2p_dev_addr=02:00:00:00:02:00 " +
+		"pri_dev_type=10-0050F204-5 name='Living Room TV' config_methods=0x188 dev_capab=0x25 group_capab=0x0").(*OnP2PDeviceFoundEvent)
+	if !ok || found.Addr != MustParseMAC("02:00:00:00:02:01") || found.DevAddr != MustParseMAC("02:00:00:00:02:00") ||
+		found.Name != "Living Room TV" || found.ConfigMethods != 0x188 {
+		t.Fatalf("wrong event %+v", found)
+	}
+
+	req, ok := parseSupplicantEvent("P2P-GO-NEG-REQUEST 02:00:00:00:02:00 dev_passwd_id=4 go_intent=7").(*OnP2PGoNegRequestEvent)
+	if !ok || req.Addr != MustParseMAC("02:00:00:00:02:00") || req.DevPasswdID != 4 || req.GOIntent != 7 {
+		t.Fatalf("wrong event %+v", req)
+	}
+
+	neg, ok := parseSupplicantEvent("P2P-GO-NEG-SUCCESS role=client freq=2437 ht40=0 peer_dev=02:00:00:00:02:00 " +
+		"peer_iface=02:00:00:00:02:01 wps_method=PBC").(*OnP2PGoNegSuccessEvent)
+	if !ok || neg.Role != "client" || neg.Freq != 2437 || neg.PeerIface != MustParseMAC("02:00:00:00:02:01") || neg.WPSMethod != "PBC" {
+		t.Fatalf("wrong event %+v", neg)
+	}
+
+	if fail, ok := parseSupplicantEvent("P2P-GO-NEG-FAILURE status=9").(*OnP2PGoNegFailureEvent); !ok || fail.Status != 9 {
+		t.Fatalf("wrong event %+v", fail)
+	}
+
+	started, ok := parseSupplicantEvent(`P2P-GROUP-STARTED p2p-wlan0-1 client ssid="DIRECT-ab" freq=2437 ` +
+		`psk=0123 go_dev_addr=02:00:00:00:02:00 [PERSISTENT]`).(*OnP2PGroupStartedEvent)
+	if !ok || started.GO || started.SSID != "DIRECT-ab" || !started.Persistent || started.GODevAddr != MustParseMAC("02:00:00:00:02:00") {
+		t.Fatalf("wrong event %+v", started)
+	}
+
+	show, ok := parseSupplicantEvent("P2P-PROV-DISC-SHOW-PIN 02:00:00:00:02:00 12345670 p2p_dev_addr=02:00:00:00:02:00").(*OnP2PProvDiscEvent)
+	if !ok || show.Type != "SHOW-PIN" || show.PIN != "12345670" || show.Addr != MustParseMAC("02:00:00:00:02:00") {
+		t.Fatalf("wrong event %+v", show)
+	}
+	fail, ok := parseSupplicantEvent("P2P-PROV-DISC-FAILURE p2p_dev_addr=02:00:00:00:02:00 status=1").(*OnP2PProvDiscEvent)
+	if !ok || fail.Type != "FAILURE" || fail.Status != 1 || fail.Addr != MustParseMAC("02:00:00:00:02:00") {
+		t.Fatalf("wrong event %+v", fail)
+	}
+}
@@ -0,0 +1,319 @@
+// Package proxy serves a control interface over a stream connection, so that
+// supplicants on headless devices can be managed remotely without exposing
+// their unix sockets.
+//
+// Each datagram is sent as a frame: a 4 byte big endian length followed by the
+// payload. Connections are either mutually authenticated TLS, where a client is
+// identified by its certificate's common name, or plain TCP meant to be reached
+// through an SSH forward, where every client is AnonymousIdentity.
+//
+// The client side is a conn.Conn, so wpa.NewWPACtrl works on it unchanged.
+package proxy
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jblebrun/go-wpa/conn"
+)
+
+// AnonymousIdentity is the identity of clients on connections without client
+// certificates.
+const AnonymousIdentity = "anonymous"
+
+// MaxFrame bounds the size of a single frame.
+const MaxFrame = 64 * 1024
+
+// Default timeouts for Server.
+const (
+	DefaultHandshakeTimeout = 10 * time.Second
+	DefaultIdleTimeout      = 5 * time.Minute
+)
+
+// AllowList is the set of commands a client may run, by command name.
+// The name "*" allows everything.
+type AllowList map[string]bool
+
+// NewAllowList builds an AllowList from command names.
+func NewAllowList(cmds ...string) AllowList {
+	al := make(AllowList)
+	for _, c := range cmds {
+		al[strings.ToUpper(c)] = true
+	}
+	return al
+}
+
+// Allows reports whether cmd may be run. IFNAME= and COOKIE= prefixes are
+// skipped, so the check applies to the command itself.
+func (al AllowList) Allows(cmd string) bool {
+	if al["*"] {
+		return true
+	}
+	fields := strings.Fields(cmd)
+	for len(fields) > 0 && (strings.HasPrefix(fields[0], "IFNAME=") || strings.HasPrefix(fields[0], "COOKIE=")) {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return false
+	}
+	return al[strings.ToUpper(fields[0])]
+}
+
+// Server forwards commands from stream clients to a control interface.
+type Server struct {
+	// Dial opens a connection to the control interface for each client.
+	Dial func() (conn.Conn, error)
+	// Policy maps client identities to the commands they may run. Clients
+	// without an entry are refused.
+	Policy map[string]AllowList
+	// ErrorLog receives connection errors. Defaults to the log package's logger.
+	ErrorLog *log.Logger
+	// HandshakeTimeout bounds the TLS handshake. Defaults to
+	// DefaultHandshakeTimeout.
+	HandshakeTimeout time.Duration
+	// IdleTimeout closes connections that send no command for that long.
+	// Clients that only wait for events should PING periodically. Defaults to
+	// DefaultIdleTimeout.
+	IdleTimeout time.Duration
+}
+
+func (s *Server) handshakeTimeout() time.Duration {
+	if s.HandshakeTimeout > 0 {
+		return s.HandshakeTimeout
+	}
+	return DefaultHandshakeTimeout
+}
+
+func (s *Server) idleTimeout() time.Duration {
+	if s.IdleTimeout > 0 {
+		return s.IdleTimeout
+	}
+	return DefaultIdleTimeout
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// Serve accepts clients on l until it fails. Use tls.NewListener for TLS.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := s.serveConn(c); err != nil && err != io.EOF {
+				s.logf("proxy: %s: %v", c.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// identify returns the certificate common name for TLS clients, and
+// AnonymousIdentity for anything else.
+func identify(c net.Conn) (string, error) {
+	tc, ok := c.(*tls.Conn)
+	if !ok {
+		return AnonymousIdentity, nil
+	}
+	if err := tc.Handshake(); err != nil {
+		return "", err
+	}
+	certs := tc.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", errors.New("no client certificate")
+	}
+	return certs[0].Subject.CommonName, nil
+}
+
+func (s *Server) serveConn(c net.Conn) error {
+	defer c.Close()
+
+	c.SetDeadline(time.Now().Add(s.handshakeTimeout()))
+	id, err := identify(c)
+	if err != nil {
+		return err
+	}
+	c.SetDeadline(time.Time{})
+	allow, ok := s.Policy[id]
+	if !ok {
+		return fmt.Errorf("no policy for %q", id)
+	}
+
+	upstream, err := s.Dial()
+	if err != nil {
+		return err
+	}
+	defer upstream.Close()
+
+	client := NewConn(c)
+
+	// responses and events from the control interface
+	go func() {
+		buf := make([]byte, MaxFrame)
+		for {
+			n, err := upstream.Read(buf)
+			if err != nil {
+				c.Close()
+				return
+			}
+			if _, err := client.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, MaxFrame)
+	for {
+		c.SetReadDeadline(time.Now().Add(s.idleTimeout()))
+		n, err := client.Read(buf)
+		if err != nil {
+			return err
+		}
+		cmd := string(buf[:n])
+		if !allow.Allows(cmd) {
+			s.logf("proxy: %s denied %q", id, cmd)
+			if _, err := client.Write([]byte("FAIL")); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := upstream.Write(buf[:n]); err != nil {
+			return err
+		}
+	}
+}
+
+// frameConn carries datagrams over a stream.
+type frameConn struct {
+	c net.Conn
+	r *bufio.Reader
+
+	wmu sync.Mutex
+}
+
+// NewConn wraps a stream connection to a proxy Server.
+func NewConn(c net.Conn) conn.Conn {
+	return &frameConn{c: c, r: bufio.NewReader(c)}
+}
+
+// Dial connects to a proxy over plain TCP, such as an SSH forwarded port.
+func Dial(addr string) (conn.Conn, error) {
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(c), nil
+}
+
+// DialTLS connects to a proxy over TLS.
+func DialTLS(addr string, cfg *tls.Config) (conn.Conn, error) {
+	c, err := tls.Dial("tcp", addr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(c), nil
+}
+
+// Read reads one frame. Like a datagram, it's truncated if b is too small.
+func (fc *frameConn) Read(b []byte) (int, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(fc.r, hdr[:]); err != nil {
+		return 0, err
+	}
+	size := binary.BigEndian.Uint32(hdr[:])
+	if size > MaxFrame {
+		return 0, fmt.Errorf("frame too large: %d", size)
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(fc.r, frame); err != nil {
+		return 0, err
+	}
+	return copy(b, frame), nil
+}
+
+func (fc *frameConn) Write(b []byte) (int, error) {
+	if len(b) > MaxFrame {
+		return 0, fmt.Errorf("frame too large: %d", len(b))
+	}
+	msg := make([]byte, 4+len(b))
+	binary.BigEndian.PutUint32(msg, uint32(len(b)))
+	copy(msg[4:], b)
+
+	fc.wmu.Lock()
+	defer fc.wmu.Unlock()
+	if _, err := fc.c.Write(msg); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (fc *frameConn) Close() error {
+	return fc.c.Close()
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in %s", caFile)
+	}
+	return pool, nil
+}
+
+// ServerTLSConfig loads a TLS config that requires client certificates signed
+// by the CA in caFile.
+func ServerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := loadCertPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ClientTLSConfig loads a TLS config that presents a client certificate and
+// verifies the server against the CA in caFile.
+func ClientTLSConfig(certFile, keyFile, caFile, serverName string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := loadCertPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
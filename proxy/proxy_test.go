@@ -0,0 +1,255 @@
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	wpa "github.com/jblebrun/go-wpa"
+	"github.com/jblebrun/go-wpa/conn"
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+// startServer runs a proxy in front of a WPAProcessMock.
+func startServer(t *testing.T, l net.Listener, policy map[string]AllowList) *wpatest.WPAProcessMock {
+	lc, err := wpatest.NewTestListenConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := wpatest.NewWPAProcessMock(t, lc)
+	s := &Server{
+		Dial: func() (conn.Conn, error) {
+			return lc.Dial()
+		},
+		Policy:   policy,
+		ErrorLog: log.New(ioutil.Discard, "", 0),
+	}
+	go s.Serve(l)
+	return mock
+}
+
+func TestAllowList(t *testing.T) {
+	al := NewAllowList("status", "SCAN")
+	cases := map[string]bool{
+		"STATUS":              true,
+		"SCAN TYPE=ONLY":      true,
+		"IFNAME=wlan0 STATUS": true,
+		"REMOVE_NETWORK 0":    false,
+		"IFNAME=wlan0":        false,
+		"":                    false,
+	}
+	for cmd, expect := range cases {
+		if al.Allows(cmd) != expect {
+			t.Fatal("wrong result for", cmd)
+		}
+	}
+	if !NewAllowList("*").Allows("REMOVE_NETWORK 0") {
+		t.Fatal("wildcard should allow everything")
+	}
+}
+
+func TestProxyTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	mock := startServer(t, l, map[string]AllowList{
+		AnonymousIdentity: NewAllowList("PING", "ATTACH", "ADD_NETWORK"),
+	})
+
+	c, err := Dial(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := wpa.NewWPASupplicantCtrl(wpa.NewWPACtrl(c, time.Second), time.Second)
+
+	if rsp, err := ctrl.Ctrl().Command("PING"); err != nil || rsp != "PONG" {
+		t.Fatal("bad ping", rsp, err)
+	}
+	if _, err := ctrl.AddNetwork(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.RemoveNetwork("0"); err == nil || err.Error() != "FAIL" {
+		t.Fatal("expected denied command to FAIL", err)
+	}
+
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+	mock.SendUnsol("<2>CTRL-EVENT-CONNECTED - Connection to 00:1a:dd:18:a4:25 completed [id=0 id_str=]")
+	select {
+	case evt := <-ctrl.Events():
+		if _, ok := evt.(*wpa.OnConnectedEvent); !ok {
+			t.Fatalf("wrong event %+v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+}
+
+type testPKI struct {
+	ca    *x509.Certificate
+	caKey *ecdsa.PrivateKey
+	pool  *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return &testPKI{ca: ca, caKey: key, pool: pool}
+}
+
+func (p *testPKI) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestProxyTLS(t *testing.T) {
+	pki := newTestPKI(t)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{pki.issue(t, "device", x509.ExtKeyUsageServerAuth)},
+		ClientCAs:    pki.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	startServer(t, l, map[string]AllowList{
+		"operator": NewAllowList("PING"),
+	})
+
+	dial := func(cn string) *wpa.WPACtrl {
+		c, err := DialTLS(l.Addr().String(), &tls.Config{
+			Certificates: []tls.Certificate{pki.issue(t, cn, x509.ExtKeyUsageClientAuth)},
+			RootCAs:      pki.pool,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wpa.NewWPACtrl(c, 500*time.Millisecond)
+	}
+
+	if rsp, err := dial("operator").Command("PING"); err != nil || rsp != "PONG" {
+		t.Fatal("bad ping", rsp, err)
+	}
+	if _, err := dial("intruder").Command("PING"); err == nil {
+		t.Fatal("expected unknown identity to be refused")
+	}
+}
+
+func TestProxyTimeouts(t *testing.T) {
+	pki := newTestPKI(t)
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	tl, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{pki.issue(t, "device", x509.ExtKeyUsageServerAuth)},
+		ClientCAs:    pki.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+
+	lc, err := wpatest.NewTestListenConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wpatest.NewWPAProcessMock(t, lc)
+	s := &Server{
+		Dial: func() (conn.Conn, error) {
+			return lc.Dial()
+		},
+		Policy:           map[string]AllowList{AnonymousIdentity: NewAllowList("PING")},
+		ErrorLog:         log.New(ioutil.Discard, "", 0),
+		HandshakeTimeout: 50 * time.Millisecond,
+		IdleTimeout:      100 * time.Millisecond,
+	}
+	go s.Serve(tcp)
+	go s.Serve(tl)
+
+	// closed waits for the server to close c.
+	closed := func(c net.Conn, what string) {
+		c.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if _, err := c.Read(make([]byte, 1)); err == nil {
+			t.Fatal(what, "got data")
+		} else if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			t.Fatal(what, "not closed")
+		}
+	}
+
+	// A client that never starts the TLS handshake.
+	raw, err := net.Dial("tcp", tl.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	closed(raw, "silent TLS client")
+
+	// Commands keep a client connected; silence disconnects it.
+	c, err := net.Dial("tcp", tcp.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctrl := wpa.NewWPACtrl(NewConn(c), time.Second)
+	for i := 0; i < 3; i++ {
+		time.Sleep(60 * time.Millisecond)
+		if rsp, err := ctrl.Command("PING"); err != nil || rsp != "PONG" {
+			t.Fatal("bad ping", rsp, err)
+		}
+	}
+	closed(c, "idle client")
+}
@@ -0,0 +1,157 @@
+package wpa
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Roam reassociates with bssid, which must be in the BSS table and belong to
+// the current network.
+func (c *WPASupplicantCtrl) Roam(bssid MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("ROAM %s", bssid))
+}
+
+// SetNetworkBSSID pins network id to bssid, so it only associates with that
+// AP. A zero bssid removes the pin.
+func (c *WPASupplicantCtrl) SetNetworkBSSID(network string, bssid MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("BSSID %s %s", network, bssid))
+}
+
+// SetBSSID pins the network to bssid. A zero bssid removes the pin.
+func (n *NetworkConfig) SetBSSID(bssid MAC) {
+	if bssid.IsZero() {
+		n.Unset("bssid")
+		return
+	}
+	n.Set("bssid", bssid.String())
+}
+
+// BSSFlush removes the BSS entries that haven't been seen in the last age
+// seconds, or all of them for 0.
+func (c *WPASupplicantCtrl) BSSFlush(age int) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("BSS_FLUSH %d", age))
+}
+
+// BSSExpireAge sets how many seconds a BSS is kept after it was last seen.
+func (c *WPASupplicantCtrl) BSSExpireAge(seconds int) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("BSS_EXPIRE_AGE %d", seconds))
+}
+
+// BSSExpireCount sets how many scans can miss a BSS before it's removed.
+func (c *WPASupplicantCtrl) BSSExpireCount(count int) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("BSS_EXPIRE_COUNT %d", count))
+}
+
+// WNMBSSQuery sends a BSS Transition Management Query to the AP, asking it to
+// suggest a better AP. reason is the 802.11 transition reason code, e.g. 16
+// for low RSSI. With list set, the candidates from the last scan are included.
+func (c *WPASupplicantCtrl) WNMBSSQuery(reason int, list bool) error {
+	cmd := fmt.Sprintf("WNM_BSS_QUERY %d", reason)
+	if list {
+		cmd += " list"
+	}
+	return c.ctrl.OkCommand(cmd)
+}
+
+// NeighborRepRequest asks the AP for a neighbor report, for ssid or the current
+// SSID if empty. Each neighbor is reported with RRM-NEIGHBOR-REP-RECEIVED.
+func (c *WPASupplicantCtrl) NeighborRepRequest(ssid string, lci, civic bool) error {
+	args := []string{"NEIGHBOR_REP_REQUEST"}
+	if ssid != "" {
+		args = append(args, "ssid="+QuoteString(ssid))
+	}
+	if lci {
+		args = append(args, "lci")
+	}
+	if civic {
+		args = append(args, "civic")
+	}
+	return c.ctrl.OkCommand(strings.Join(args, " "))
+}
+
+// NeighborReport is one entry of a neighbor report.
+type NeighborReport struct {
+	BSSID MAC
+	// Info is the BSSID Information field; bit 0-1 is AP reachability.
+	Info    int
+	OpClass int
+	Channel int
+	PhyType int
+}
+
+// OnNeighborReportEvent is RRM-NEIGHBOR-REP-RECEIVED.
+type OnNeighborReportEvent struct {
+	baseEvent
+	Report NeighborReport
+}
+
+func NewOnNeighborReportEvent(msg string) *OnNeighborReportEvent {
+	f := parseEventFields(msg)
+	bssid, _ := ParseMAC(f["bssid"])
+	return &OnNeighborReportEvent{
+		baseEvent: baseEvent{msg},
+		Report: NeighborReport{
+			BSSID:   bssid,
+			Info:    parseHexInt(f["info"]),
+			OpClass: atoi(f["op_class"]),
+			Channel: atoi(f["chan"]),
+			PhyType: atoi(f["phy_type"]),
+		},
+	}
+}
+
+// OnNeighborReportFailedEvent is RRM-NEIGHBOR-REP-REQUEST-FAILED.
+type OnNeighborReportFailedEvent struct{ baseEvent }
+
+// OnBSSTMEvent is one of the BSS Transition Management events,
+// CTRL-EVENT-BSS-TM-* or BSS-TM-*.
+type OnBSSTMEvent struct {
+	baseEvent
+	// Type is the part of the event name after BSS-TM-, e.g. REQ, RESP or
+	// QUERY.
+	Type        string
+	Addr        MAC
+	DialogToken int
+	StatusCode  int
+	TargetBSSID MAC
+}
+
+func NewOnBSSTMEvent(msg string) *OnBSSTMEvent {
+	name := strings.Fields(msg)[0]
+	f := parseEventFields(msg)
+	evt := &OnBSSTMEvent{
+		baseEvent:   baseEvent{msg},
+		Type:        name[strings.Index(name, "BSS-TM-")+len("BSS-TM-"):],
+		Addr:        firstMAC(msg),
+		DialogToken: atoi(f["dialog_token"]),
+		StatusCode:  atoi(f["status_code"]),
+	}
+	if evt.Addr.IsZero() {
+		evt.Addr, _ = ParseMAC(f["addr"])
+	}
+	evt.TargetBSSID, _ = ParseMAC(f["target_bssid"])
+	return evt
+}
+
+// OnESSDisassocImminentEvent is ESS-DISASSOC-IMMINENT, sent when the AP
+// announces the whole ESS is going away. URL points to the session information.
+type OnESSDisassocImminentEvent struct {
+	baseEvent
+	PMF bool
+	// ReauthDelay is how long, in seconds, not to reassociate to the ESS.
+	ReauthDelay int
+	URL         string
+}
+
+func NewOnESSDisassocImminentEvent(msg string) *OnESSDisassocImminentEvent {
+	evt := &OnESSDisassocImminentEvent{baseEvent: baseEvent{msg}}
+	if f := strings.Fields(msg); len(f) > 3 {
+		evt.PMF = f[1] == "1"
+		evt.ReauthDelay = atoi(f[2])
+		evt.URL = f[3]
+	}
+	return evt
+}
+
+// OnWNMEvent is any other WNM-* event.
+type OnWNMEvent struct{ baseEvent }
@@ -0,0 +1,371 @@
+package wpa
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// RoamPolicy decides when a Roamer scans and which BSS it roams to.
+// Implementations are given the time explicitly so they can be driven
+// deterministically.
+type RoamPolicy interface {
+	// ShouldScan is called with each signal poll of the current connection
+	// and reports whether to scan for roam candidates.
+	ShouldScan(now time.Time, sig *SignalInfo) bool
+	// Select picks a BSS to roam to from scan results. current is the BSS
+	// in use, with Signal set from the last signal poll.
+	Select(now time.Time, current ScanResult, results []ScanResult) (MAC, bool)
+	// Result reports whether a roam to bssid succeeded.
+	Result(now time.Time, bssid MAC, ok bool)
+}
+
+type RoamPolicyConfig struct {
+	// ScanRSSI is the signal, in dBm, below which to look for a better BSS.
+	ScanRSSI int
+	// MinRSSI is the weakest signal a candidate may have.
+	MinRSSI int
+	// Hysteresis is how many dB a candidate must beat the current BSS by,
+	// after the band bonus.
+	Hysteresis int
+	// BandBonus is added to the signal of 5 and 6 GHz BSSes when comparing,
+	// to prefer them over 2.4 GHz.
+	BandBonus int
+	// ScanInterval is the minimum time between scans.
+	ScanInterval time.Duration
+	// After MaxFailures failed roams to a BSS it is blocked for BlockDuration.
+	MaxFailures   int
+	BlockDuration time.Duration
+}
+
+var DefaultRoamPolicyConfig = RoamPolicyConfig{
+	ScanRSSI:      -70,
+	MinRSSI:       -80,
+	Hysteresis:    8,
+	BandBonus:     5,
+	ScanInterval:  30 * time.Second,
+	MaxFailures:   2,
+	BlockDuration: 5 * time.Minute,
+}
+
+// ThresholdRoamPolicy scans when the signal drops below a threshold, and roams
+// to the strongest BSS of the same SSID that beats the current one by the
+// hysteresis. BSSes that repeatedly fail are blocked for a while.
+type ThresholdRoamPolicy struct {
+	cfg RoamPolicyConfig
+
+	mu       sync.Mutex
+	lastScan time.Time
+	failures map[MAC]int
+	blocked  map[MAC]time.Time
+}
+
+func NewThresholdRoamPolicy(cfg RoamPolicyConfig) *ThresholdRoamPolicy {
+	return &ThresholdRoamPolicy{
+		cfg:      cfg,
+		failures: make(map[MAC]int),
+		blocked:  make(map[MAC]time.Time),
+	}
+}
+
+func (p *ThresholdRoamPolicy) ShouldScan(now time.Time, sig *SignalInfo) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if sig.RSSI >= p.cfg.ScanRSSI {
+		return false
+	}
+	if !p.lastScan.IsZero() && now.Sub(p.lastScan) < p.cfg.ScanInterval {
+		return false
+	}
+	p.lastScan = now
+	return true
+}
+
+// score is the signal used to compare BSSes, including the band bonus.
+func (p *ThresholdRoamPolicy) score(r ScanResult) int {
+	if r.Freq >= 5000 {
+		return r.Signal + p.cfg.BandBonus
+	}
+	return r.Signal
+}
+
+func (p *ThresholdRoamPolicy) Select(now time.Time, current ScanResult, results []ScanResult) (MAC, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var best *ScanResult
+	for i := range results {
+		r := &results[i]
+		if r.SSID != current.SSID || r.BSSID == current.BSSID || r.Signal < p.cfg.MinRSSI {
+			continue
+		}
+		if p.isBlocked(r.BSSID, now) {
+			continue
+		}
+		if best == nil || p.score(*r) > p.score(*best) {
+			best = r
+		}
+	}
+	if best == nil || p.score(*best) < p.score(current)+p.cfg.Hysteresis {
+		return MAC{}, false
+	}
+	return best.BSSID, true
+}
+
+func (p *ThresholdRoamPolicy) Result(now time.Time, bssid MAC, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ok {
+		delete(p.failures, bssid)
+		return
+	}
+	p.failures[bssid]++
+	if p.failures[bssid] >= p.cfg.MaxFailures {
+		delete(p.failures, bssid)
+		p.blocked[bssid] = now.Add(p.cfg.BlockDuration)
+	}
+}
+
+// Blocked reports whether bssid is excluded from Select at now.
+func (p *ThresholdRoamPolicy) Blocked(bssid MAC, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.isBlocked(bssid, now)
+}
+
+func (p *ThresholdRoamPolicy) isBlocked(bssid MAC, now time.Time) bool {
+	until, ok := p.blocked[bssid]
+	if !ok {
+		return false
+	}
+	if !now.Before(until) {
+		delete(p.blocked, bssid)
+		return false
+	}
+	return true
+}
+
+// RoamAttempt is emitted by Roamer for every roam it tries.
+type RoamAttempt struct {
+	From MAC
+	To   MAC
+	OK   bool
+	// Err is set when ROAM failed, or the connection didn't complete.
+	Err error
+}
+
+var (
+	errScanTimeout = errors.New("timed out waiting for scan results")
+	errScanFailed  = errors.New("scan failed")
+	errRoamTimeout = errors.New("timed out waiting for roam")
+	errRoamDisconn = errors.New("disconnected while roaming")
+)
+
+// Roamer periodically polls the signal of the current connection and roams
+// when its RoamPolicy finds a better BSS. The ctrl must be attached for the
+// Roamer to see scan and connection events.
+type Roamer struct {
+	c        *WPASupplicantCtrl
+	policy   RoamPolicy
+	interval time.Duration
+	events   chan RoamAttempt
+
+	// ScanTimeout and RoamTimeout bound the waits for scan results and for
+	// the connection to the new BSS.
+	ScanTimeout time.Duration
+	RoamTimeout time.Duration
+
+	now func() time.Time
+
+	stop chan struct{}
+
+	mu      sync.Mutex
+	started bool
+	stopped bool
+	closed  bool
+}
+
+func NewRoamer(c *WPASupplicantCtrl, policy RoamPolicy, interval time.Duration) *Roamer {
+	return &Roamer{
+		c:           c,
+		policy:      policy,
+		interval:    interval,
+		events:      make(chan RoamAttempt, 16),
+		ScanTimeout: 5 * time.Second,
+		RoamTimeout: 10 * time.Second,
+		now:         time.Now,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start calls Step every interval until Stop. It does nothing after Stop.
+func (r *Roamer) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started || r.stopped {
+		return
+	}
+	r.started = true
+	go r.run()
+}
+
+// Stop ends roaming and closes the Events channel, right away if Start was
+// never called, or else once a Step in progress has finished.
+func (r *Roamer) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+	r.stopped = true
+	close(r.stop)
+	if !r.started {
+		r.closeEvents()
+	}
+}
+
+// closeEvents closes the Events channel. r.mu must be held.
+func (r *Roamer) closeEvents() {
+	r.closed = true
+	close(r.events)
+}
+
+// emit sends an attempt to Events, unless it's full or closed.
+func (r *Roamer) emit(a RoamAttempt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.events <- a:
+	default:
+	}
+}
+
+// Events returns the roam attempts. Attempts are dropped if it isn't read.
+func (r *Roamer) Events() <-chan RoamAttempt {
+	return r.events
+}
+
+func (r *Roamer) run() {
+	defer func() {
+		r.mu.Lock()
+		r.closeEvents()
+		r.mu.Unlock()
+	}()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.Step()
+		}
+	}
+}
+
+// Step polls the signal once and, if the policy asks for it, scans and roams.
+// It returns the attempt made, or nil if there was none.
+func (r *Roamer) Step() (*RoamAttempt, error) {
+	status, err := r.c.Status()
+	if err != nil {
+		return nil, err
+	}
+	if status.WPAState != "COMPLETED" {
+		return nil, nil
+	}
+	sig, err := r.c.SignalPoll()
+	if err != nil {
+		return nil, err
+	}
+	if !r.policy.ShouldScan(r.now(), sig) {
+		return nil, nil
+	}
+
+	results, err := r.scan()
+	if err != nil {
+		return nil, err
+	}
+	current := ScanResult{BSSID: status.BSSID, Freq: status.Freq, Signal: sig.RSSI, SSID: status.SSID}
+	target, ok := r.policy.Select(r.now(), current, results)
+	if !ok {
+		return nil, nil
+	}
+
+	attempt := &RoamAttempt{From: status.BSSID, To: target}
+	attempt.Err = r.roam(target)
+	attempt.OK = attempt.Err == nil
+	r.policy.Result(r.now(), target, attempt.OK)
+	r.emit(*attempt)
+	return attempt, nil
+}
+
+// isScanDone matches the events that end a scan. Scans also send an event for
+// each BSS found, which could otherwise fill the subscription.
+func isScanDone(evt WPASupplicantEvent) bool {
+	switch evt.(type) {
+	case *OnScanResultsEvent, *OnScanFailedEvent:
+		return true
+	}
+	return false
+}
+
+func isConnectionChange(evt WPASupplicantEvent) bool {
+	switch evt.(type) {
+	case *OnConnectedEvent, *OnDisconnectedEvent:
+		return true
+	}
+	return false
+}
+
+func (r *Roamer) scan() ([]ScanResult, error) {
+	events := r.c.subscribe(isScanDone)
+	defer r.c.unsubscribe(events)
+	if err := r.c.Scan(); err != nil {
+		return nil, err
+	}
+	timeout := time.After(r.ScanTimeout)
+	for {
+		select {
+		case evt := <-events:
+			switch evt.(type) {
+			case *OnScanResultsEvent:
+				return r.c.ScanResults()
+			case *OnScanFailedEvent:
+				return nil, errScanFailed
+			}
+		case <-timeout:
+			return nil, errScanTimeout
+		}
+	}
+}
+
+// roam roams to target and waits for the connection. Drivers without SME send
+// a locally generated disconnect before associating with the new BSS, so
+// only other disconnects count as failure.
+func (r *Roamer) roam(target MAC) error {
+	events := r.c.subscribe(isConnectionChange)
+	defer r.c.unsubscribe(events)
+	if err := r.c.Roam(target); err != nil {
+		return err
+	}
+	timeout := time.After(r.RoamTimeout)
+	for {
+		select {
+		case evt := <-events:
+			switch e := evt.(type) {
+			case *OnConnectedEvent:
+				if e.BSSID == target {
+					return nil
+				}
+			case *OnDisconnectedEvent:
+				if !e.LocallyGenerated {
+					return errRoamDisconn
+				}
+			}
+		case <-timeout:
+			return errRoamTimeout
+		}
+	}
+}
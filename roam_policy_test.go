@@ -0,0 +1,231 @@
+package wpa
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var (
+	bss24  = MustParseMAC("02:00:00:00:01:00")
+	bss5   = MustParseMAC("02:00:00:00:02:00")
+	bss6   = MustParseMAC("02:00:00:00:04:00")
+	bssOff = MustParseMAC("02:00:00:00:03:00")
+	// bss24b is a second 2.4 GHz BSS.
+	bss24b = MustParseMAC("02:00:00:00:05:00")
+)
+
+func TestRoamPolicyShouldScan(t *testing.T) {
+	p := NewThresholdRoamPolicy(DefaultRoamPolicyConfig)
+	t0 := time.Unix(1000, 0)
+
+	steps := []struct {
+		at   time.Duration
+		rssi int
+		scan bool
+	}{
+		{0, -60, false},
+		{time.Second, -74, true},
+		// Rate limited by ScanInterval.
+		{10 * time.Second, -78, false},
+		{31 * time.Second, -65, false},
+		{32 * time.Second, -75, true},
+	}
+	for i, s := range steps {
+		if got := p.ShouldScan(t0.Add(s.at), &SignalInfo{RSSI: s.rssi}); got != s.scan {
+			t.Errorf("step %d: ShouldScan(%d) = %v", i, s.rssi, got)
+		}
+	}
+}
+
+func TestRoamPolicySelect(t *testing.T) {
+	p := NewThresholdRoamPolicy(DefaultRoamPolicyConfig)
+	now := time.Unix(1000, 0)
+	current := ScanResult{BSSID: bss24, Freq: 2437, Signal: -75, SSID: "office"}
+
+	tests := []struct {
+		name    string
+		results []ScanResult
+		target  MAC
+		ok      bool
+	}{
+		{"none", nil, MAC{}, false},
+		{"other ssid", []ScanResult{
+			{BSSID: bssOff, Freq: 5745, Signal: -50, SSID: "guest"},
+		}, MAC{}, false},
+		{"within hysteresis", []ScanResult{
+			{BSSID: bss24, Freq: 2437, Signal: -75, SSID: "office"},
+			{BSSID: bss24b, Freq: 2412, Signal: -70, SSID: "office"},
+		}, MAC{}, false},
+		{"band bonus beats hysteresis", []ScanResult{
+			{BSSID: bss5, Freq: 5180, Signal: -72, SSID: "office"},
+		}, bss5, true},
+		{"prefers 6 GHz over 2.4 GHz", []ScanResult{
+			{BSSID: bss24b, Freq: 2462, Signal: -62, SSID: "office"},
+			{BSSID: bss6, Freq: 5955, Signal: -64, SSID: "office"},
+		}, bss6, true},
+		{"below min rssi", []ScanResult{
+			{BSSID: bss5, Freq: 5180, Signal: -82, SSID: "office"},
+		}, MAC{}, false},
+	}
+	for _, tt := range tests {
+		target, ok := p.Select(now, current, tt.results)
+		if ok != tt.ok || target != tt.target {
+			t.Errorf("%s: got %s %v", tt.name, target, ok)
+		}
+	}
+}
+
+func TestRoamPolicyBlocklist(t *testing.T) {
+	p := NewThresholdRoamPolicy(DefaultRoamPolicyConfig)
+	t0 := time.Unix(1000, 0)
+	current := ScanResult{BSSID: bss24, Freq: 2437, Signal: -78, SSID: "office"}
+	results := []ScanResult{
+		{BSSID: bss5, Freq: 5180, Signal: -55, SSID: "office"},
+		{BSSID: bss6, Freq: 5955, Signal: -65, SSID: "office"},
+	}
+
+	p.Result(t0, bss5, false)
+	if p.Blocked(bss5, t0) {
+		t.Fatal("blocked after one failure")
+	}
+	if target, _ := p.Select(t0, current, results); target != bss5 {
+		t.Fatal("wrong target", target)
+	}
+
+	p.Result(t0, bss5, false)
+	if !p.Blocked(bss5, t0) {
+		t.Fatal("not blocked after MaxFailures")
+	}
+	if target, _ := p.Select(t0.Add(time.Minute), current, results); target != bss6 {
+		t.Fatal("blocked BSS not skipped", target)
+	}
+
+	later := t0.Add(DefaultRoamPolicyConfig.BlockDuration)
+	if p.Blocked(bss5, later) {
+		t.Fatal("still blocked after BlockDuration")
+	}
+	if target, _ := p.Select(later, current, results); target != bss5 {
+		t.Fatal("wrong target after unblock", target)
+	}
+
+	// A success resets the failure count.
+	p.Result(later, bss5, false)
+	p.Result(later, bss5, true)
+	p.Result(later, bss5, false)
+	if p.Blocked(bss5, later) {
+		t.Fatal("failures not reset by success")
+	}
+}
+
+func TestRoamerStep(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	r := NewRoamer(ctrl, NewThresholdRoamPolicy(DefaultRoamPolicyConfig), time.Second)
+	r.now = func() time.Time { return now }
+
+	// Strong signal: nothing to do.
+	mock.SetSignalPoll("RSSI=-60\nFREQUENCY=2437")
+	attempt, err := r.Step()
+	if err != nil || attempt != nil {
+		t.Fatal("unexpected roam", attempt, err)
+	}
+
+	mock.SetSignalPoll("RSSI=-75\nFREQUENCY=2437")
+	attempt, err = r.Step()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt == nil || !attempt.OK || attempt.From != bss24 || attempt.To != bss5 {
+		t.Fatalf("wrong attempt %+v", attempt)
+	}
+	select {
+	case evt := <-r.Events():
+		if evt != *attempt {
+			t.Fatalf("wrong event %+v", evt)
+		}
+	default:
+		t.Fatal("no roam event")
+	}
+	status, err := ctrl.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.BSSID != bss5 || status.Freq != 5180 {
+		t.Fatalf("didn't roam %+v", status)
+	}
+
+	// Still weak, but within ScanInterval of the last scan.
+	now = now.Add(10 * time.Second)
+	if attempt, err := r.Step(); err != nil || attempt != nil {
+		t.Fatal("unexpected roam", attempt, err)
+	}
+
+	// The only stronger BSS is on another SSID.
+	now = now.Add(time.Minute)
+	mock.SetScanResults("bssid / frequency / signal level / flags / ssid\n" +
+		"02:00:00:00:02:00\t5180\t-75\t[ESS]\toffice\n" +
+		"02:00:00:00:03:00\t5745\t-50\t[ESS]\tguest")
+	if attempt, err := r.Step(); err != nil || attempt != nil {
+		t.Fatal("unexpected roam", attempt, err)
+	}
+}
+
+func TestRoamerNonSME(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRoamer(ctrl, NewThresholdRoamPolicy(DefaultRoamPolicyConfig), time.Second)
+
+	// More BSS-ADDED events than a subscription holds arrive before
+	// SCAN-RESULTS.
+	results := "bssid / frequency / signal level / flags / ssid\n" +
+		"02:00:00:00:01:00\t2437\t-75\t[ESS]\toffice\n" +
+		"02:00:00:00:02:00\t5180\t-66\t[ESS]\toffice"
+	for i := 0; i < 40; i++ {
+		results += fmt.Sprintf("\n02:00:00:00:10:%02x\t5745\t-50\t[ESS]\tguest", i)
+	}
+	mock.SetScanResults(results)
+	mock.SetSignalPoll("RSSI=-75\nFREQUENCY=2437")
+	// ROAM disconnects locally before connecting to the target.
+	mock.SetNonSMERoam(true)
+
+	attempt, err := r.Step()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt == nil || !attempt.OK || attempt.To != bss5 {
+		t.Fatalf("wrong attempt %+v", attempt)
+	}
+}
+
+func TestDisconnectedLocallyGenerated(t *testing.T) {
+	evt := parseSupplicantEvent("CTRL-EVENT-DISCONNECTED bssid=02:00:00:00:01:00 reason=3 locally_generated=1")
+	if d, ok := evt.(*OnDisconnectedEvent); !ok || !d.LocallyGenerated {
+		t.Fatalf("wrong event %+v", evt)
+	}
+	evt = parseSupplicantEvent("CTRL-EVENT-DISCONNECTED bssid=02:00:00:00:01:00 reason=4")
+	if d, ok := evt.(*OnDisconnectedEvent); !ok || d.LocallyGenerated {
+		t.Fatalf("wrong event %+v", evt)
+	}
+}
+
+func TestRoamerStopWithoutStart(t *testing.T) {
+	_, ctrl := NewWPASupplicantTest(t)
+	r := NewRoamer(ctrl, NewThresholdRoamPolicy(DefaultRoamPolicyConfig), time.Second)
+	r.Stop()
+	r.Stop()
+	select {
+	case _, ok := <-r.Events():
+		if ok {
+			t.Fatal("unexpected attempt")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("events not closed")
+	}
+	r.Start()
+}
@@ -0,0 +1,98 @@
+package wpa
+
+import "testing"
+
+func TestRoamCommands(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	bssid := MustParseMAC("02:00:00:00:01:00")
+
+	if err := ctrl.Roam(bssid); err != nil {
+		t.Fatal(err)
+	}
+	id, err := ctrl.AddNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.Expect("BSSID 0 02:00:00:00:01:00", "OK")
+	if err := ctrl.SetNetworkBSSID(id, bssid); err != nil {
+		t.Fatal(err)
+	}
+	mock.Expect("BSSID 0 00:00:00:00:00:00", "OK")
+	if err := ctrl.SetNetworkBSSID(id, MAC{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.SetNetworkBSSID("4", bssid); err == nil {
+		t.Fatal("expected unknown network to fail")
+	}
+
+	if err := ctrl.BSSFlush(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.BSSExpireAge(30); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.BSSExpireCount(2); err != nil {
+		t.Fatal(err)
+	}
+	mock.Expect("WNM_BSS_QUERY 16 list", "OK")
+	if err := ctrl.WNMBSSQuery(16, true); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewNetworkConfig()
+	cfg.SetBSSID(bssid)
+	if v, _ := cfg.Get("bssid"); v != "02:00:00:00:01:00" {
+		t.Fatal("wrong bssid", v)
+	}
+	cfg.SetBSSID(MAC{})
+	if _, ok := cfg.Get("bssid"); ok {
+		t.Fatal("expected bssid to be unset")
+	}
+}
+
+func TestNeighborReport(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.Expect(`NEIGHBOR_REP_REQUEST ssid="office" lci`, "OK")
+	if err := ctrl.NeighborRepRequest("office", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.NeighborRepRequest("", false, false); err != nil {
+		t.Fatal(err)
+	}
+	expect := []NeighborReport{
+		{BSSID: MustParseMAC("02:00:00:00:01:00"), Info: 0x8f, OpClass: 115, Channel: 36, PhyType: 9},
+		{BSSID: MustParseMAC("02:00:00:00:02:00"), Info: 0x8f, OpClass: 81, Channel: 6, PhyType: 7},
+	}
+	for _, e := range expect {
+		evt, ok := nextSupplicantEvent(t, ctrl).(*OnNeighborReportEvent)
+		if !ok || evt.Report != e {
+			t.Fatalf("wrong event %+v", evt)
+		}
+	}
+}
+
+func TestBSSTMEvents(t *testing.T) {
+	req, ok := parseSupplicantEvent("CTRL-EVENT-BSS-TM-REQ addr=02:00:00:00:01:00 dialog_token=3 target_bssid=02:00:00:00:02:00").(*OnBSSTMEvent)
+	if !ok || req.Type != "REQ" || req.Addr != MustParseMAC("02:00:00:00:01:00") || req.DialogToken != 3 ||
+		req.TargetBSSID != MustParseMAC("02:00:00:00:02:00") {
+		t.Fatalf("wrong event %+v", req)
+	}
+	resp, ok := parseSupplicantEvent("BSS-TM-RESP 02:00:00:00:03:00 dialog_token=3 status_code=6 bss_termination_delay=0").(*OnBSSTMEvent)
+	if !ok || resp.Type != "RESP" || resp.Addr != MustParseMAC("02:00:00:00:03:00") || resp.StatusCode != 6 {
+		t.Fatalf("wrong event %+v", resp)
+	}
+	ess, ok := parseSupplicantEvent("ESS-DISASSOC-IMMINENT 1 300 http://example.com/session").(*OnESSDisassocImminentEvent)
+	if !ok || !ess.PMF || ess.ReauthDelay != 300 || ess.URL != "http://example.com/session" {
+		t.Fatalf("wrong event %+v", ess)
+	}
+	if _, ok := parseSupplicantEvent("WNM-NOTIFICATION-REQ").(*OnWNMEvent); !ok {
+		t.Fatal("expected WNM event")
+	}
+	if _, ok := parseSupplicantEvent("RRM-NEIGHBOR-REP-REQUEST-FAILED").(*OnNeighborReportFailedEvent); !ok {
+		t.Fatal("expected neighbor report failure")
+	}
+}
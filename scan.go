@@ -0,0 +1,90 @@
+package wpa
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scan requests a scan. Completion is reported with CTRL-EVENT-SCAN-RESULTS.
+func (c *WPASupplicantCtrl) Scan() error {
+	return c.ctrl.OkCommand("SCAN")
+}
+
+// ScanResult is a row of SCAN_RESULTS.
+type ScanResult struct {
+	BSSID MAC
+	Freq  int
+	// Signal is the RSSI in dBm.
+	Signal int
+	// Flags are the bracketed flags, without brackets, e.g. WPA2-PSK-CCMP, ESS.
+	Flags []string
+	SSID  string
+}
+
+// ScanResults returns the BSS table from the last scans.
+func (c *WPASupplicantCtrl) ScanResults() ([]ScanResult, error) {
+	rsp, err := c.ctrl.FailCommand("SCAN_RESULTS")
+	if err != nil {
+		return nil, err
+	}
+	var results []ScanResult
+	lines := strings.Split(rsp, "\n")
+	// The first line is the header.
+	for _, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
+		f := strings.SplitN(line, "\t", 5)
+		if len(f) < 4 {
+			return nil, fmt.Errorf("bad scan result %q", line)
+		}
+		bssid, err := ParseMAC(f[0])
+		if err != nil {
+			return nil, fmt.Errorf("bad scan result %q: %v", line, err)
+		}
+		r := ScanResult{BSSID: bssid, Freq: atoi(f[1]), Signal: atoi(f[2])}
+		for _, flag := range strings.Split(f[3], "]") {
+			if flag = strings.TrimPrefix(flag, "["); flag != "" {
+				r.Flags = append(r.Flags, flag)
+			}
+		}
+		if len(f) > 4 {
+			r.SSID = f[4]
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// SupplicantStatus is the response to STATUS.
+type SupplicantStatus struct {
+	// WPAState is e.g. DISCONNECTED, SCANNING, ASSOCIATING or COMPLETED.
+	WPAState string
+	BSSID    MAC
+	SSID     string
+	Freq     int
+	// ID is the network id, or empty when not connected.
+	ID      string
+	KeyMgmt string
+	IPAddr  string
+	Raw     map[string]string
+}
+
+func (c *WPASupplicantCtrl) Status() (*SupplicantStatus, error) {
+	rsp, err := c.ctrl.FailCommand("STATUS")
+	if err != nil {
+		return nil, err
+	}
+	kv := parseKeyValues(rsp)
+	bssid, _ := ParseMAC(kv["bssid"])
+	return &SupplicantStatus{
+		WPAState: kv["wpa_state"],
+		BSSID:    bssid,
+		SSID:     kv["ssid"],
+		Freq:     atoi(kv["freq"]),
+		ID:       kv["id"],
+		KeyMgmt:  kv["key_mgmt"],
+		IPAddr:   kv["ip_address"],
+		Raw:      kv,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package wpa
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanResults(t *testing.T) {
+	_, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	results, err := ctrl.ScanResults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatal("wrong results", results)
+	}
+	expect := ScanResult{
+		BSSID:  MustParseMAC("02:00:00:00:02:00"),
+		Freq:   5180,
+		Signal: -66,
+		Flags:  []string{"WPA2-PSK-CCMP", "ESS"},
+		SSID:   "office",
+	}
+	if !reflect.DeepEqual(results[1], expect) {
+		t.Fatalf("wrong result %+v", results[1])
+	}
+}
+
+func TestStatus(t *testing.T) {
+	_, ctrl := NewWPASupplicantTest(t)
+	s, err := ctrl.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.WPAState != "COMPLETED" || s.BSSID != MustParseMAC("02:00:00:00:01:00") ||
+		s.SSID != "office" || s.Freq != 2437 || s.ID != "0" || s.KeyMgmt != "WPA2-PSK" {
+		t.Fatalf("wrong status %+v", s)
+	}
+	if s.Raw["address"] != "02:00:00:00:00:01" {
+		t.Fatal("wrong raw address", s.Raw["address"])
+	}
+}
+
+func TestConnectedBSSID(t *testing.T) {
+	e := NewOnConnectedEvent("CTRL-EVENT-CONNECTED - Connection to 00:1a:dd:18:a4:25 completed [id=0 id_str=]")
+	if e.BSSID != MustParseMAC("00:1a:dd:18:a4:25") {
+		t.Fatal("wrong bssid", e.BSSID)
+	}
+}
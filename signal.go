@@ -0,0 +1,258 @@
+package wpa
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SignalInfo is the response to SIGNAL_POLL.
+type SignalInfo struct {
+	RSSI int
+	// LinkSpeed is the TX rate in Mbps.
+	LinkSpeed int
+	// Noise is 9999 when the driver doesn't report it.
+	Noise     int
+	Frequency int
+	// Width is the channel width as reported, e.g. "20 MHz".
+	Width         string
+	CenterFreq1   int
+	CenterFreq2   int
+	AvgRSSI       int
+	AvgBeaconRSSI int
+}
+
+// SignalPoll returns the signal parameters of the current connection.
+// It fails when not connected.
+func (c *WPASupplicantCtrl) SignalPoll() (*SignalInfo, error) {
+	rsp, err := c.ctrl.FailCommand("SIGNAL_POLL")
+	if err != nil {
+		return nil, err
+	}
+	kv := parseKeyValues(rsp)
+	return &SignalInfo{
+		RSSI:          atoi(kv["RSSI"]),
+		LinkSpeed:     atoi(kv["LINKSPEED"]),
+		Noise:         atoi(kv["NOISE"]),
+		Frequency:     atoi(kv["FREQUENCY"]),
+		Width:         kv["WIDTH"],
+		CenterFreq1:   atoi(kv["CENTER_FRQ1"]),
+		CenterFreq2:   atoi(kv["CENTER_FRQ2"]),
+		AvgRSSI:       atoi(kv["AVG_RSSI"]),
+		AvgBeaconRSSI: atoi(kv["AVG_BEACON_RSSI"]),
+	}, nil
+}
+
+// SetSignalMonitor asks wpa_supplicant to send CTRL-EVENT-SIGNAL-CHANGE events
+// when the RSSI crosses threshold (in dBm). A threshold of 0 disables monitoring.
+func (c *WPASupplicantCtrl) SetSignalMonitor(threshold, hysteresis int) error {
+	if threshold == 0 {
+		return c.ctrl.OkCommand("SIGNAL_MONITOR")
+	}
+	return c.ctrl.OkCommand(fmt.Sprintf("SIGNAL_MONITOR THRESHOLD=%d HYSTERESIS=%d", threshold, hysteresis))
+}
+
+// OnSignalChangeEvent is CTRL-EVENT-SIGNAL-CHANGE, sent when the RSSI crosses
+// the SIGNAL_MONITOR threshold.
+type OnSignalChangeEvent struct {
+	baseEvent
+	Above  bool
+	Signal int
+	Noise  int
+	// TxRate is in kbps.
+	TxRate int
+}
+
+func NewOnSignalChangeEvent(msg string) *OnSignalChangeEvent {
+	f := parseEventFields(msg)
+	return &OnSignalChangeEvent{
+		baseEvent: baseEvent{msg},
+		Above:     f["above"] == "1",
+		Signal:    atoi(f["signal"]),
+		Noise:     atoi(f["noise"]),
+		TxRate:    atoi(f["txrate"]),
+	}
+}
+
+type LinkQuality int
+
+const (
+	LinkUnknown LinkQuality = iota
+	LinkWeak
+	LinkFair
+	LinkGood
+)
+
+func (q LinkQuality) String() string {
+	switch q {
+	case LinkWeak:
+		return "weak"
+	case LinkFair:
+		return "fair"
+	case LinkGood:
+		return "good"
+	}
+	return "unknown"
+}
+
+// LinkQualityEvent is emitted by SignalMonitor for every poll and signal change event.
+type LinkQualityEvent struct {
+	Quality LinkQuality
+	RSSI    int
+	// Poll is set when the event came from SIGNAL_POLL, and nil when it came
+	// from CTRL-EVENT-SIGNAL-CHANGE.
+	Poll *SignalInfo
+	// Change is set when the event came from CTRL-EVENT-SIGNAL-CHANGE.
+	Change *OnSignalChangeEvent
+}
+
+type SignalMonitorConfig struct {
+	// Interval between SIGNAL_POLLs. Zero disables polling.
+	Interval time.Duration
+	// Threshold and Hysteresis are passed to SIGNAL_MONITOR. A zero Threshold
+	// leaves SIGNAL_MONITOR untouched.
+	Threshold  int
+	Hysteresis int
+	// RSSI below WeakRSSI is LinkWeak, at or above GoodRSSI is LinkGood,
+	// and anything in between is LinkFair.
+	WeakRSSI int
+	GoodRSSI int
+}
+
+var DefaultSignalMonitorConfig = SignalMonitorConfig{
+	Interval:   10 * time.Second,
+	Threshold:  -75,
+	Hysteresis: 4,
+	WeakRSSI:   -75,
+	GoodRSSI:   -60,
+}
+
+// SignalMonitor tracks link quality by polling SIGNAL_POLL and listening for
+// CTRL-EVENT-SIGNAL-CHANGE.
+type SignalMonitor struct {
+	c      *WPASupplicantCtrl
+	cfg    SignalMonitorConfig
+	events chan LinkQualityEvent
+
+	stop chan struct{}
+
+	mu      sync.Mutex
+	started bool
+	stopped bool
+	closed  bool
+}
+
+func NewSignalMonitor(c *WPASupplicantCtrl, cfg SignalMonitorConfig) *SignalMonitor {
+	return &SignalMonitor{
+		c:      c,
+		cfg:    cfg,
+		events: make(chan LinkQualityEvent, 16),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start configures SIGNAL_MONITOR and begins polling. The ctrl must be attached
+// to receive signal change events. It does nothing once started or stopped.
+func (m *SignalMonitor) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started || m.stopped {
+		return nil
+	}
+	if m.cfg.Threshold != 0 {
+		if err := m.c.SetSignalMonitor(m.cfg.Threshold, m.cfg.Hysteresis); err != nil {
+			return err
+		}
+	}
+	m.started = true
+	go m.run(m.c.subscribe(func(evt WPASupplicantEvent) bool {
+		_, ok := evt.(*OnSignalChangeEvent)
+		return ok
+	}))
+	return nil
+}
+
+// Stop ends monitoring and closes the Events channel, right away if Start was
+// never called.
+func (m *SignalMonitor) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return nil
+	}
+	m.stopped = true
+	close(m.stop)
+	if !m.started {
+		m.closeEvents()
+		return nil
+	}
+	if m.cfg.Threshold != 0 {
+		return m.c.SetSignalMonitor(0, 0)
+	}
+	return nil
+}
+
+// closeEvents closes the Events channel. m.mu must be held.
+func (m *SignalMonitor) closeEvents() {
+	m.closed = true
+	close(m.events)
+}
+
+func (m *SignalMonitor) Events() <-chan LinkQualityEvent {
+	return m.events
+}
+
+// Classify maps an RSSI to a LinkQuality using the configured thresholds.
+func (m *SignalMonitor) Classify(rssi int) LinkQuality {
+	switch {
+	case rssi == 0:
+		return LinkUnknown
+	case rssi < m.cfg.WeakRSSI:
+		return LinkWeak
+	case rssi >= m.cfg.GoodRSSI:
+		return LinkGood
+	}
+	return LinkFair
+}
+
+func (m *SignalMonitor) run(events chan WPASupplicantEvent) {
+	defer func() {
+		m.mu.Lock()
+		m.closeEvents()
+		m.mu.Unlock()
+	}()
+	defer m.c.unsubscribe(events)
+
+	var tick <-chan time.Time
+	if m.cfg.Interval > 0 {
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		var evt LinkQualityEvent
+		select {
+		case <-m.stop:
+			return
+		case <-tick:
+			info, err := m.c.SignalPoll()
+			if err != nil {
+				continue
+			}
+			evt = LinkQualityEvent{Quality: m.Classify(info.RSSI), RSSI: info.RSSI, Poll: info}
+		case e := <-events:
+			sc, ok := e.(*OnSignalChangeEvent)
+			if !ok {
+				continue
+			}
+			evt = LinkQualityEvent{Quality: m.Classify(sc.Signal), RSSI: sc.Signal, Change: sc}
+		}
+
+		select {
+		case m.events <- evt:
+		case <-m.stop:
+			return
+		}
+	}
+}
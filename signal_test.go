@@ -0,0 +1,126 @@
+package wpa
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignalPoll(t *testing.T) {
+	_, ctrl := NewWPASupplicantTest(t)
+
+	info, err := ctrl.SignalPoll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := SignalInfo{
+		RSSI:          -62,
+		LinkSpeed:     144,
+		Noise:         9999,
+		Frequency:     5180,
+		Width:         "80 MHz",
+		CenterFreq1:   5210,
+		AvgRSSI:       -60,
+		AvgBeaconRSSI: -61,
+	}
+	if *info != expect {
+		t.Fatalf("wrong signal info %+v", info)
+	}
+}
+
+func TestSignalPollFail(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	mock.Expect("SIGNAL_POLL", "FAIL")
+	if _, err := ctrl.SignalPoll(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSignalChangeMsg(t *testing.T) {
+	e := NewOnSignalChangeEvent("CTRL-EVENT-SIGNAL-CHANGE above=0 signal=-78 noise=-95 txrate=6500")
+	if e.Above || e.Signal != -78 || e.Noise != -95 || e.TxRate != 6500 {
+		t.Fatalf("wrong event %+v", e)
+	}
+}
+
+func TestSignalMonitor(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultSignalMonitorConfig
+	cfg.Interval = 10 * time.Millisecond
+	mon := NewSignalMonitor(ctrl, cfg)
+	mock.Expect("SIGNAL_MONITOR THRESHOLD=-75 HYSTERESIS=4", "OK")
+	if err := mon.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case evt := <-mon.Events():
+		if evt.Poll == nil || evt.Quality != LinkFair || evt.RSSI != -62 {
+			t.Fatalf("wrong poll event %+v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no poll event")
+	}
+
+	// ctrl.Events() isn't read; the monitor must still see every change.
+	mock.SendUnsol("<3>CTRL-EVENT-SIGNAL-CHANGE above=0 signal=-80 noise=-95 txrate=6500")
+	mock.SendUnsol("<3>CTRL-EVENT-SIGNAL-CHANGE above=1 signal=-55 noise=-95 txrate=6500")
+
+	timeout := time.After(time.Second)
+	var changes []LinkQualityEvent
+	for len(changes) < 2 {
+		select {
+		case evt := <-mon.Events():
+			if evt.Change != nil {
+				changes = append(changes, evt)
+			}
+		case <-timeout:
+			t.Fatal("missing change events", changes)
+		}
+	}
+	if changes[0].Quality != LinkWeak || changes[0].RSSI != -80 {
+		t.Fatalf("wrong change event %+v", changes[0])
+	}
+	if changes[1].Quality != LinkGood || changes[1].RSSI != -55 {
+		t.Fatalf("wrong change event %+v", changes[1])
+	}
+	if err := mon.Stop(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignalMonitorStartStop(t *testing.T) {
+	_, ctrl := NewWPASupplicantTest(t)
+	cfg := SignalMonitorConfig{Interval: time.Hour}
+
+	mon := NewSignalMonitor(ctrl, cfg)
+	if err := mon.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-mon.Events(); ok {
+		t.Fatal("events not closed")
+	}
+	if err := mon.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	mon = NewSignalMonitor(ctrl, cfg)
+	for i := 0; i < 2; i++ {
+		if err := mon.Start(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mon.Stop()
+	mon.Stop()
+	select {
+	case _, ok := <-mon.Events():
+		if ok {
+			t.Fatal("unexpected event")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("events not closed")
+	}
+}
@@ -0,0 +1,86 @@
+package wpa
+
+// PacketCounters is the response to PKTCNT_POLL.
+type PacketCounters struct {
+	TxGood int
+	TxBad  int
+	RxGood int
+}
+
+// PacketCounters returns the driver's TX/RX packet counters.
+func (c *WPASupplicantCtrl) PacketCounters() (*PacketCounters, error) {
+	rsp, err := c.ctrl.FailCommand("PKTCNT_POLL")
+	if err != nil {
+		return nil, err
+	}
+	kv := parseKeyValues(rsp)
+	return &PacketCounters{
+		TxGood: atoi(kv["TXGOOD"]),
+		TxBad:  atoi(kv["TXBAD"]),
+		RxGood: atoi(kv["RXGOOD"]),
+	}, nil
+}
+
+// MIB holds the commonly used dot11RSNA and dot1xSupp variables from the MIB
+// command. Everything the supplicant reported is available in Raw.
+type MIB struct {
+	RSNAEnabled                  bool
+	RSNAAuthenticationSuite      string
+	RSNAPairwiseCipher           string
+	RSNAGroupCipher              string
+	RSNAPMKIDUsed                string
+	RSNA4WayHandshakeFailures    int
+	SuppPaeState                 int
+	SuppBackendPaeState          int
+	SuppPortAuthorized           bool
+	EapolFramesReceived          int
+	EapolFramesTransmitted       int
+	EapolStartFramesTransmitted  int
+	EapolLogoffFramesTransmitted int
+	EapolRespIdFramesTransmitted int
+	EapolRespFramesTransmitted   int
+	EapolReqIdFramesReceived     int
+	EapolReqFramesReceived       int
+	InvalidEapolFramesReceived   int
+	EapLengthErrorFramesReceived int
+	LastEapolFrameVersion        int
+	LastEapolFrameSource         string
+	Raw                          map[string]string
+}
+
+// MIB returns the supplicant's MIB variables.
+func (c *WPASupplicantCtrl) MIB() (*MIB, error) {
+	rsp, err := c.ctrl.FailCommand("MIB")
+	if err != nil {
+		return nil, err
+	}
+	return parseMIB(rsp), nil
+}
+
+func parseMIB(rsp string) *MIB {
+	kv := parseKeyValues(rsp)
+	return &MIB{
+		RSNAEnabled:                  kv["dot11RSNAEnabled"] == "TRUE",
+		RSNAAuthenticationSuite:      kv["dot11RSNAAuthenticationSuiteSelected"],
+		RSNAPairwiseCipher:           kv["dot11RSNAPairwiseCipherSelected"],
+		RSNAGroupCipher:              kv["dot11RSNAGroupCipherSelected"],
+		RSNAPMKIDUsed:                kv["dot11RSNAPMKIDUsed"],
+		RSNA4WayHandshakeFailures:    atoi(kv["dot11RSNA4WayHandshakeFailures"]),
+		SuppPaeState:                 atoi(kv["dot1xSuppPaeState"]),
+		SuppBackendPaeState:          atoi(kv["dot1xSuppBackendPaeState"]),
+		SuppPortAuthorized:           kv["dot1xSuppSuppControlledPortStatus"] == "Authorized",
+		EapolFramesReceived:          atoi(kv["dot1xSuppEapolFramesReceived"]),
+		EapolFramesTransmitted:       atoi(kv["dot1xSuppEapolFramesTransmitted"]),
+		EapolStartFramesTransmitted:  atoi(kv["dot1xSuppEapolStartFramesTransmitted"]),
+		EapolLogoffFramesTransmitted: atoi(kv["dot1xSuppEapolLogoffFramesTransmitted"]),
+		EapolRespIdFramesTransmitted: atoi(kv["dot1xSuppEapolRespIdFramesTransmitted"]),
+		EapolRespFramesTransmitted:   atoi(kv["dot1xSuppEapolRespFramesTransmitted"]),
+		EapolReqIdFramesReceived:     atoi(kv["dot1xSuppEapolReqIdFramesReceived"]),
+		EapolReqFramesReceived:       atoi(kv["dot1xSuppEapolReqFramesReceived"]),
+		InvalidEapolFramesReceived:   atoi(kv["dot1xSuppInvalidEapolFramesReceived"]),
+		EapLengthErrorFramesReceived: atoi(kv["dot1xSuppEapLengthErrorFramesReceived"]),
+		LastEapolFrameVersion:        atoi(kv["dot1xSuppLastEapolFrameVersion"]),
+		LastEapolFrameSource:         kv["dot1xSuppLastEapolFrameSource"],
+		Raw:                          kv,
+	}
+}
@@ -0,0 +1,36 @@
+package wpa
+
+import "testing"
+
+func TestPacketCounters(t *testing.T) {
+	_, ctrl := NewWPASupplicantTest(t)
+
+	pc, err := ctrl.PacketCounters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pc != (PacketCounters{TxGood: 10452, TxBad: 17, RxGood: 23871}) {
+		t.Fatalf("wrong counters %+v", pc)
+	}
+}
+
+func TestMIB(t *testing.T) {
+	_, ctrl := NewWPASupplicantTest(t)
+
+	mib, err := ctrl.MIB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mib.RSNAEnabled || !mib.SuppPortAuthorized {
+		t.Fatalf("wrong flags %+v", mib)
+	}
+	if mib.RSNAPairwiseCipher != "00-0f-ac-4" || mib.RSNA4WayHandshakeFailures != 2 {
+		t.Fatalf("wrong rsna %+v", mib)
+	}
+	if mib.EapolFramesReceived != 4 || mib.LastEapolFrameSource != "00:1a:dd:18:a4:25" {
+		t.Fatalf("wrong dot1x %+v", mib)
+	}
+	if mib.Raw["dot1xSuppHeldPeriod"] != "60" {
+		t.Fatal("missing raw value", mib.Raw)
+	}
+}
@@ -0,0 +1,106 @@
+package wpa
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TDLSLinkStatus is the status reported by TDLS_LINK_STATUS.
+type TDLSLinkStatus string
+
+const (
+	TDLSConnected    TDLSLinkStatus = "connected"
+	TDLSNotConnected TDLSLinkStatus = "peer not connected"
+	TDLSPeerNotExist TDLSLinkStatus = "peer does not exist"
+	TDLSDisabled     TDLSLinkStatus = "disabled"
+)
+
+// TDLSDiscover sends a TDLS discovery request to peer through the AP.
+func (c *WPASupplicantCtrl) TDLSDiscover(peer MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("TDLS_DISCOVER %s", peer))
+}
+
+// TDLSSetup sets up a direct link with peer, which must be associated with the
+// same AP.
+func (c *WPASupplicantCtrl) TDLSSetup(peer MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("TDLS_SETUP %s", peer))
+}
+
+// TDLSTeardown tears down the direct link with peer.
+func (c *WPASupplicantCtrl) TDLSTeardown(peer MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("TDLS_TEARDOWN %s", peer))
+}
+
+// TDLSLinkStatus returns the state of the direct link with peer.
+func (c *WPASupplicantCtrl) TDLSLinkStatus(peer MAC) (TDLSLinkStatus, error) {
+	rsp, err := c.ctrl.FailCommand(fmt.Sprintf("TDLS_LINK_STATUS %s", peer))
+	if err != nil {
+		return "", err
+	}
+	const prefix = "TDLS link status:"
+	if !strings.HasPrefix(rsp, prefix) {
+		return "", fmt.Errorf("bad link status %q", rsp)
+	}
+	return TDLSLinkStatus(strings.TrimSpace(rsp[len(prefix):])), nil
+}
+
+// TDLSChanSwitchParams are the TDLS_CHAN_SWITCH parameters. Zero values are
+// omitted.
+type TDLSChanSwitchParams struct {
+	OperClass int
+	Freq      int
+	// SecChannelOffset is -1 or 1 for 40 MHz operation below or above Freq.
+	SecChannelOffset int
+	CenterFreq1      int
+	CenterFreq2      int
+	// Bandwidth in MHz.
+	Bandwidth int
+	HT        bool
+	VHT       bool
+}
+
+// TDLSChanSwitch moves the direct link with peer to an off-channel, while the
+// station stays associated with the AP.
+func (c *WPASupplicantCtrl) TDLSChanSwitch(peer MAC, p TDLSChanSwitchParams) error {
+	if p.OperClass <= 0 || p.Freq <= 0 {
+		return errors.New("oper class and freq are required")
+	}
+	args := []string{"TDLS_CHAN_SWITCH", peer.String(), fmt.Sprintf("%d %d", p.OperClass, p.Freq)}
+	addInt := func(name string, v int) {
+		if v != 0 {
+			args = append(args, fmt.Sprintf("%s=%d", name, v))
+		}
+	}
+	addInt("sec_channel_offset", p.SecChannelOffset)
+	addInt("center_freq1", p.CenterFreq1)
+	addInt("center_freq2", p.CenterFreq2)
+	addInt("bandwidth", p.Bandwidth)
+	if p.HT {
+		args = append(args, "ht")
+	}
+	if p.VHT {
+		args = append(args, "vht")
+	}
+	return c.ctrl.OkCommand(strings.Join(args, " "))
+}
+
+// TDLSCancelChanSwitch returns the direct link with peer to the base channel.
+func (c *WPASupplicantCtrl) TDLSCancelChanSwitch(peer MAC) error {
+	return c.ctrl.OkCommand(fmt.Sprintf("TDLS_CANCEL_CHAN_SWITCH %s", peer))
+}
+
+// OnTDLSConnectedEvent is TDLS-CONNECTED, sent when the direct link to Addr is
+// up.
+type OnTDLSConnectedEvent struct {
+	baseEvent
+	Addr MAC
+}
+
+// OnTDLSDisconnectedEvent is TDLS-DISCONNECTED. Reason is the 802.11 reason
+// code, when given.
+type OnTDLSDisconnectedEvent struct {
+	baseEvent
+	Addr   MAC
+	Reason int
+}
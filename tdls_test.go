@@ -0,0 +1,65 @@
+package wpa
+
+import (
+	"testing"
+
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+func TestTDLS(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+	peer := MustParseMAC(wpatest.TDLSPeer)
+	other := MustParseMAC("02:00:00:00:09:00")
+
+	if err := ctrl.TDLSDiscover(peer); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := ctrl.TDLSLinkStatus(other); err != nil || status != TDLSPeerNotExist {
+		t.Fatal(status, err)
+	}
+	if status, err := ctrl.TDLSLinkStatus(peer); err != nil || status != TDLSNotConnected {
+		t.Fatal(status, err)
+	}
+	if err := ctrl.TDLSChanSwitch(peer, TDLSChanSwitchParams{OperClass: 115, Freq: 5180}); err == nil {
+		t.Fatal("expected channel switch without a link to fail")
+	}
+
+	if err := ctrl.TDLSSetup(peer); err != nil {
+		t.Fatal(err)
+	}
+	connected, ok := nextSupplicantEvent(t, ctrl).(*OnTDLSConnectedEvent)
+	if !ok || connected.Addr != peer {
+		t.Fatalf("wrong event %+v", connected)
+	}
+	if status, err := ctrl.TDLSLinkStatus(peer); err != nil || status != TDLSConnected {
+		t.Fatal(status, err)
+	}
+
+	mock.Expect("TDLS_CHAN_SWITCH 02:00:00:00:08:00 116 5180 sec_channel_offset=1 bandwidth=40 ht", "OK")
+	err := ctrl.TDLSChanSwitch(peer, TDLSChanSwitchParams{OperClass: 116, Freq: 5180, SecChannelOffset: 1, Bandwidth: 40, HT: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.TDLSChanSwitch(peer, TDLSChanSwitchParams{Freq: 5180}); err == nil {
+		t.Fatal("expected missing oper class error")
+	}
+	if err := ctrl.TDLSCancelChanSwitch(peer); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ctrl.TDLSTeardown(peer); err != nil {
+		t.Fatal(err)
+	}
+	disconnected, ok := nextSupplicantEvent(t, ctrl).(*OnTDLSDisconnectedEvent)
+	if !ok || disconnected.Addr != peer || disconnected.Reason != 3 {
+		t.Fatalf("wrong event %+v", disconnected)
+	}
+
+	mock.Expect("TDLS_LINK_STATUS 02:00:00:00:08:00", "garbage")
+	if _, err := ctrl.TDLSLinkStatus(peer); err == nil {
+		t.Fatal("expected bad status error")
+	}
+}
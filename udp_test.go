@@ -0,0 +1,90 @@
+package wpa
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jblebrun/go-wpa/conn"
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+func NewUDPTest(t *testing.T) (*wpatest.UDPListenConn, *WPACtrl) {
+	lc, err := wpatest.NewUDPListenConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wpatest.NewWPAProcessMock(t, lc)
+
+	c, err := conn.NewUDPConn(lc.Addr(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lc, NewWPACtrl(c, 100*time.Millisecond)
+}
+
+func TestUDPCommand(t *testing.T) {
+	lc, ctrl := NewUDPTest(t)
+	defer lc.Close()
+	defer ctrl.Close()
+
+	rsp, err := ctrl.Command("PING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp != "PONG" {
+		t.Fatal("rsp not ok: ", rsp)
+	}
+}
+
+func TestUDPCookieRotation(t *testing.T) {
+	lc, ctrl := NewUDPTest(t)
+	defer lc.Close()
+	defer ctrl.Close()
+
+	lc.RotateCookie()
+
+	rsp, err := ctrl.Command("PING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp != "PONG" {
+		t.Fatal("rsp not ok: ", rsp)
+	}
+}
+
+// slowConn never replies to commands, and refreshes to the same cookie.
+type slowConn struct {
+	writes chan string
+	done   chan struct{}
+}
+
+func (c *slowConn) Write(b []byte) (int, error) {
+	c.writes <- string(b)
+	return len(b), nil
+}
+
+func (c *slowConn) Read([]byte) (int, error) {
+	<-c.done
+	return 0, errors.New("closed")
+}
+
+func (c *slowConn) Close() error {
+	close(c.done)
+	return nil
+}
+
+func (c *slowConn) RefreshCookie() (bool, error) { return false, nil }
+
+func TestSlowCommandNotRetried(t *testing.T) {
+	c := &slowConn{writes: make(chan string, 4), done: make(chan struct{})}
+	ctrl := NewWPACtrl(c, 10*time.Millisecond)
+	defer c.Close()
+
+	if _, err := ctrl.Command("ADD_NETWORK"); err != ErrTimeout {
+		t.Fatal("expected timeout, got", err)
+	}
+	if len(c.writes) != 1 {
+		t.Fatal("command was sent", len(c.writes), "times")
+	}
+}
@@ -0,0 +1,119 @@
+package wpa
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Values for ieee80211w, management frame protection.
+const (
+	PMFDisabled = 0
+	PMFOptional = 1
+	PMFRequired = 2
+)
+
+// Values for sae_pwe, how the SAE password element is derived.
+const (
+	SAEHuntAndPeck   = 0
+	SAEHashToElement = 1
+	SAEBoth          = 2
+)
+
+// SetSAEPassword sets the SAE password. Unlike a WPA passphrase it has no
+// length limits. Without it, SAE uses psk.
+func (n *NetworkConfig) SetSAEPassword(password string) {
+	n.SetString("sae_password", password)
+}
+
+// SetSAEPasswordID sets the identifier the AP uses to select the password.
+func (n *NetworkConfig) SetSAEPasswordID(id string) {
+	n.SetString("sae_password_id", id)
+}
+
+// SetSAEPWE selects hunting-and-pecking, hash-to-element or both.
+func (n *NetworkConfig) SetSAEPWE(pwe int) {
+	n.Set("sae_pwe", strconv.Itoa(pwe))
+}
+
+// IEEE80211W returns the management frame protection setting, or -1 if unset.
+func (n *NetworkConfig) IEEE80211W() int {
+	v, ok := n.Get("ieee80211w")
+	if !ok {
+		return -1
+	}
+	return atoi(v)
+}
+
+func (n *NetworkConfig) SetIEEE80211W(pmf int) {
+	n.Set("ieee80211w", strconv.Itoa(pmf))
+}
+
+// SetWPA3Personal configures an SAE only network. PMF is required. With
+// extKey, SAE-EXT-KEY is offered as well, which needs hash-to-element.
+func (n *NetworkConfig) SetWPA3Personal(password string, extKey bool) {
+	if extKey {
+		n.SetKeyMgmt("SAE", "SAE-EXT-KEY")
+		n.SetSAEPWE(SAEBoth)
+	} else {
+		n.SetKeyMgmt("SAE")
+	}
+	n.SetSAEPassword(password)
+	n.SetIEEE80211W(PMFRequired)
+}
+
+// SetWPA2WPA3Transition configures a transition mode network, which connects
+// with SAE to WPA3 APs and with WPA-PSK to WPA2 APs. The passphrase is used
+// for both, so it must be a valid WPA passphrase. PMF is optional, since WPA2
+// stations may not support it.
+func (n *NetworkConfig) SetWPA2WPA3Transition(passphrase string) error {
+	if l := len(passphrase); l < 8 || l > 63 {
+		return fmt.Errorf("passphrase must be 8 to 63 characters, not %d", l)
+	}
+	n.SetKeyMgmt("WPA-PSK", "SAE")
+	n.SetString("psk", passphrase)
+	n.Unset("sae_password")
+	n.SetIEEE80211W(PMFOptional)
+	return nil
+}
+
+// SetOWE configures an Opportunistic Wireless Encryption network. PMF is
+// required. OWE transition mode APs are found through their open BSS
+// automatically.
+func (n *NetworkConfig) SetOWE() {
+	n.SetKeyMgmt("OWE")
+	n.SetIEEE80211W(PMFRequired)
+}
+
+// SupportedKeyMgmt returns the key management suites the driver and build
+// support, from GET_CAPABILITY key_mgmt.
+func (c *WPASupplicantCtrl) SupportedKeyMgmt() ([]string, error) {
+	rsp, err := c.ctrl.FailCommand("GET_CAPABILITY key_mgmt")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(rsp), nil
+}
+
+// CheckKeyMgmt fails if cfg uses a key management suite that isn't supported.
+func (c *WPASupplicantCtrl) CheckKeyMgmt(cfg *NetworkConfig) error {
+	supported, err := c.SupportedKeyMgmt()
+	if err != nil {
+		return err
+	}
+	for _, km := range cfg.KeyMgmt() {
+		if km != "NONE" && !hasAny(supported, km) {
+			return fmt.Errorf("key_mgmt %s is not supported", km)
+		}
+	}
+	return nil
+}
+
+// ConfigureNetworkChecked is ConfigureNetwork, after CheckKeyMgmt. Nothing is
+// applied if the key management isn't supported.
+func (c *WPASupplicantCtrl) ConfigureNetworkChecked(network string, cfg *NetworkConfig) error {
+	if err := c.CheckKeyMgmt(cfg); err != nil {
+		return err
+	}
+	return c.ConfigureNetwork(network, cfg)
+}
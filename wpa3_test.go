@@ -0,0 +1,66 @@
+package wpa
+
+import (
+	"testing"
+
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+func TestWPA3Config(t *testing.T) {
+	cfg := NewNetworkConfig()
+	if err := cfg.SetWPA2WPA3Transition("short"); err == nil {
+		t.Fatal("expected passphrase error")
+	}
+	if err := cfg.SetWPA2WPA3Transition("correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if km := cfg.KeyMgmt(); len(km) != 2 || km[0] != "WPA-PSK" || km[1] != "SAE" {
+		t.Fatal("wrong key_mgmt", km)
+	}
+	if cfg.IEEE80211W() != PMFOptional {
+		t.Fatal("wrong ieee80211w", cfg.IEEE80211W())
+	}
+
+	cfg = NewNetworkConfig()
+	cfg.SetWPA3Personal("x", true)
+	cfg.SetSAEPasswordID("guest")
+	expect := []NetworkVar{
+		{"key_mgmt", "SAE SAE-EXT-KEY"},
+		{"sae_pwe", "2"},
+		{"sae_password", `"x"`},
+		{"ieee80211w", "2"},
+		{"sae_password_id", `"guest"`},
+	}
+	vars := cfg.Vars()
+	if len(vars) != len(expect) {
+		t.Fatalf("wrong vars %v", vars)
+	}
+	for i := range expect {
+		if vars[i] != expect[i] {
+			t.Fatalf("var %d: expected %v, got %v", i, expect[i], vars[i])
+		}
+	}
+
+	if NewNetworkConfig().IEEE80211W() != -1 {
+		t.Fatal("expected unset ieee80211w")
+	}
+}
+
+func TestConfigureNetworkChecked(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+
+	cfg := NewNetworkConfig()
+	cfg.SetWPA3Personal("secret", true)
+	if err := ctrl.ConfigureNetworkChecked("0", cfg); err == nil {
+		t.Fatal("expected SAE-EXT-KEY to be unsupported")
+	}
+
+	cfg = NewNetworkConfig()
+	cfg.SetOWE()
+	mock.Expect("GET_CAPABILITY key_mgmt", wpatest.KeyMgmtCapabilityReply)
+	mock.Expect("SET_NETWORK 0 key_mgmt OWE", "OK")
+	mock.Expect("SET_NETWORK 0 ieee80211w 2", "OK")
+	if err := ctrl.ConfigureNetworkChecked("0", cfg); err != nil {
+		t.Fatal(err)
+	}
+}
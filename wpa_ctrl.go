@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,6 +29,10 @@ type WPACtrl struct {
 
 	c Conn
 
+	// cmdMu serializes commands, since replies carry nothing to match them
+	// to the command that was sent.
+	cmdMu sync.Mutex
+
 	cmdTimeout time.Duration
 }
 
@@ -59,27 +65,40 @@ func (wc *WPACtrl) receiveLoop() {
 			return
 		}
 
-		if buf[0] == byte('<') {
+		msg := buf[:n]
+
+		// The global control interface prefixes per-interface events
+		// with the interface name: "IFNAME=wlan0 <3>CTRL-EVENT-...".
+		// Keep the prefix so the events can be routed.
+		ifname := ""
+		if strings.HasPrefix(string(msg), "IFNAME=") {
+			if sp := strings.IndexByte(string(msg), ' '); sp > 0 && sp+1 < n && msg[sp+1] == byte('<') {
+				ifname = string(msg[:sp+1])
+				msg = msg[sp+1:]
+			}
+		}
+
+		if len(msg) > 0 && msg[0] == byte('<') {
 			// sanity check - should be <P> where P is a single digit priority
-			if len(buf) < 3 || buf[2] != byte('>') {
+			if len(msg) < 3 || msg[2] != byte('>') {
 				/*
 					logrus.WithFields(logrus.Fields{
 						"event": "invalid-solicited-msg",
-						"msg":   string(buf[:n]),
+						"msg":   string(msg),
 					}).Error()
 				*/
 			} else {
 				// we don't care about the priority prefix for now
-				wc.unsolicited <- strings.TrimSpace(string(buf[3:n]))
+				wc.unsolicited <- ifname + strings.TrimSpace(string(msg[3:]))
 			}
 		} else {
 			select {
-			case wc.solicited <- strings.TrimSpace(string(buf[:n])):
+			case wc.solicited <- strings.TrimSpace(string(msg)):
 			default:
 				/*
 					logrus.WithFields(logrus.Fields{
 						"event": "unexpected-solicited-msg",
-						"msg":   string(buf[:n]),
+						"msg":   string(msg),
 					}).Error()
 				*/
 			}
@@ -114,6 +133,48 @@ func (wc *WPACtrl) Command(cmd string) (string, error) {
 			"cmd":   cmd,
 		}).Info()
 	*/
+	wc.cmdMu.Lock()
+	defer wc.cmdMu.Unlock()
+
+	rsp, err := wc.command(cmd)
+	if err != ErrTimeout {
+		return rsp, err
+	}
+
+	// A UDP control interface drops commands with a stale cookie, which
+	// looks like a timeout. If the cookie really was stale, try once more.
+	// Otherwise the command may just have been slow, and running it again
+	// could repeat commands like ADD_NETWORK.
+	if cr, ok := wc.c.(cookieRefresher); ok {
+		changed, err := cr.RefreshCookie()
+		if err != nil {
+			return "", err
+		}
+		if changed {
+			return wc.command(cmd)
+		}
+	}
+	return "", ErrTimeout
+}
+
+// cookieRefresher is implemented by Conns that authenticate commands with a
+// cookie, like the UDP control interface. RefreshCookie reports whether the
+// cookie changed.
+type cookieRefresher interface {
+	RefreshCookie() (bool, error)
+}
+
+// drainSolicited drops a late reply to an earlier command that timed out, so
+// it isn't taken as the reply to the next one.
+func (wc *WPACtrl) drainSolicited() {
+	select {
+	case <-wc.solicited:
+	default:
+	}
+}
+
+func (wc *WPACtrl) command(cmd string) (string, error) {
+	wc.drainSolicited()
 	_, err := wc.c.Write([]byte(cmd))
 	if err != nil {
 		return "", fmt.Errorf("command error: %v", err)
@@ -172,3 +233,59 @@ func (c *WPACtrl) Attach() error {
 func (c *WPACtrl) Detach() error {
 	return c.OkCommand("DETACH")
 }
+
+// parseKeyValues parses the key=value lines returned by commands like STATUS
+// and SIGNAL_POLL. Lines without an = are ignored.
+func parseKeyValues(rsp string) map[string]string {
+	kv := make(map[string]string)
+	for _, line := range strings.Split(rsp, "\n") {
+		i := strings.IndexByte(line, '=')
+		if i <= 0 {
+			continue
+		}
+		kv[line[:i]] = strings.TrimSpace(line[i+1:])
+	}
+	return kv
+}
+
+// parseEventFields parses the space separated key=value fields of an event
+// message. Values may be wrapped in single or double quotes to include spaces.
+func parseEventFields(msg string) map[string]string {
+	kv := make(map[string]string)
+	for len(msg) > 0 {
+		msg = strings.TrimLeft(msg, " ")
+		end := strings.IndexByte(msg, ' ')
+		eq := strings.IndexByte(msg, '=')
+		if eq < 0 {
+			break
+		}
+		if end >= 0 && end < eq {
+			// not a key=value field, skip it
+			msg = msg[end:]
+			continue
+		}
+		key := msg[:eq]
+		msg = msg[eq+1:]
+		if len(msg) > 0 && (msg[0] == '\'' || msg[0] == '"') {
+			q := msg[0]
+			if j := strings.IndexByte(msg[1:], q); j >= 0 {
+				kv[key] = msg[1 : j+1]
+				msg = msg[j+2:]
+				continue
+			}
+		}
+		end = strings.IndexByte(msg, ' ')
+		if end < 0 {
+			end = len(msg)
+		}
+		kv[key] = msg[:end]
+		msg = msg[end:]
+	}
+	return kv
+}
+
+// atoi parses an integer field, treating anything unparseable as 0.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
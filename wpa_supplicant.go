@@ -4,13 +4,21 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Wrap WPACtrl with commands for wpa_supplicant
 type WPASupplicantCtrl struct {
-	ctrl   Ctrl
-	events chan WPASupplicantEvent
+	ctrl       Ctrl
+	cmdTimeout time.Duration
+	events     chan WPASupplicantEvent
+
+	mu           sync.Mutex
+	listeners    []listener
+	credProvider CredentialProvider
+	credError    func(req *OnCredentialRequestEvent, err error)
+	blacklistCmd string
 }
 
 type WPASupplicantEvent interface {
@@ -23,10 +31,17 @@ type baseEvent struct {
 
 func (e *baseEvent) WPAString() string { return e.raw }
 
-type OnConnectedEvent struct{ baseEvent }
+// OnConnectedEvent is CTRL-EVENT-CONNECTED. BSSID is the AP connected to.
+type OnConnectedEvent struct {
+	baseEvent
+	BSSID MAC
+}
 type OnDisconnectedEvent struct {
 	baseEvent
 	reason string
+	// LocallyGenerated is set when wpa_supplicant itself disconnected, e.g.
+	// to roam on drivers without SME.
+	LocallyGenerated bool
 }
 type OnNotFoundEvent struct{ baseEvent }
 type OnScanFailedEvent struct{ baseEvent }
@@ -78,12 +93,26 @@ var commonReasonCodes = map[string]string{
 	"45": "peer-cipher-suite-not-supported",
 }
 
+var cre = regexp.MustCompile("Connection to ([0-9a-fA-F:]{17})")
+
+func NewOnConnectedEvent(msg string) *OnConnectedEvent {
+	evt := &OnConnectedEvent{baseEvent: baseEvent{msg}}
+	if found := cre.FindStringSubmatch(msg); len(found) == 2 {
+		evt.BSSID, _ = ParseMAC(found[1])
+	}
+	return evt
+}
+
 func NewOnDisconnectedEvent(msg string) *OnDisconnectedEvent {
 	reason := parseReason(msg)
 	sreason := commonReasonCodes[reason]
 	sreason = fmt.Sprintf("%s:%s", reason, sreason)
 
-	return &OnDisconnectedEvent{baseEvent: baseEvent{msg}, reason: sreason}
+	return &OnDisconnectedEvent{
+		baseEvent:        baseEvent{msg},
+		reason:           sreason,
+		LocallyGenerated: parseEventFields(msg)["locally_generated"] == "1",
+	}
 }
 
 func (e *OnDisconnectedEvent) Reason() string {
@@ -105,35 +134,176 @@ type Ctrl interface {
 
 func NewWPASupplicantCtrl(ctrl Ctrl, cmdTimeout time.Duration) *WPASupplicantCtrl {
 	supCtrl := &WPASupplicantCtrl{
-		ctrl:   ctrl,
-		events: make(chan WPASupplicantEvent),
+		ctrl:       ctrl,
+		cmdTimeout: cmdTimeout,
+		events:     make(chan WPASupplicantEvent),
 	}
 
+	queue := make(chan WPASupplicantEvent)
+	go forwardEvents(queue, supCtrl.events)
 	go func() {
+		defer close(queue)
 		for msg := range ctrl.Unsolicited() {
-			if strings.HasPrefix(msg, "CTRL-EVENT-CONNECTED") {
-				supCtrl.events <- &OnConnectedEvent{baseEvent: baseEvent{msg}}
-			} else if strings.HasPrefix(msg, "CTRL-EVENT-DISCONNECTED") {
-				supCtrl.events <- NewOnDisconnectedEvent(msg)
-			} else if strings.HasPrefix(msg, "CTRL-EVENT-NETWORK-NOT-FOUND") {
-				supCtrl.events <- &OnNotFoundEvent{baseEvent: baseEvent{msg}}
-			} else if strings.HasPrefix(msg, "CTRL-EVENT-SCAN-FAILED") {
-				supCtrl.events <- &OnScanFailedEvent{baseEvent: baseEvent{msg}}
-			} else if strings.HasPrefix(msg, "CTRL-EVENT-SCAN-STARTED") {
-				supCtrl.events <- &OnScanStartedEvent{baseEvent: baseEvent{msg}}
-			} else if strings.HasPrefix(msg, "CTRL-EVENT-SCAN-RESULTS") {
-				supCtrl.events <- &OnScanResultsEvent{baseEvent: baseEvent{msg}}
-			} else if strings.HasPrefix(msg, "CTRL-EVENT-BSS-ADDED") {
-				supCtrl.events <- &OnScanEvent{baseEvent: baseEvent{msg}}
-			} else {
-				supCtrl.events <- &OnEvent{baseEvent: baseEvent{msg}}
+			evt := parseSupplicantEvent(msg)
+			supCtrl.notify(evt)
+			if req, ok := evt.(*OnCredentialRequestEvent); ok {
+				supCtrl.provideCredential(req)
 			}
+			queue <- evt
 		}
 	}()
 
 	return supCtrl
 }
 
+// eventBacklog is how many events are held for Events() while it isn't read.
+// Beyond that the oldest are dropped.
+const eventBacklog = 1024
+
+// forwardEvents queues events from in for Events(), so a caller that doesn't
+// read it doesn't hold up the listeners or the control socket.
+func forwardEvents(in <-chan WPASupplicantEvent, events chan<- WPASupplicantEvent) {
+	var queue []WPASupplicantEvent
+	for in != nil || len(queue) > 0 {
+		var out chan<- WPASupplicantEvent
+		var next WPASupplicantEvent
+		if len(queue) > 0 {
+			out, next = events, queue[0]
+		}
+		select {
+		case evt, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			if len(queue) == eventBacklog {
+				queue = queue[1:]
+			}
+			queue = append(queue, evt)
+		case out <- next:
+			queue = queue[1:]
+		}
+	}
+}
+
+func parseSupplicantEvent(msg string) WPASupplicantEvent {
+	if evt := parseWPSEvent(msg); evt != nil {
+		return evt
+	}
+	if evt := parseDPPEvent(msg); evt != nil {
+		return evt
+	}
+	if evt := parseP2PEvent(msg); evt != nil {
+		return evt
+	}
+	switch {
+	case strings.HasPrefix(msg, "CTRL-EVENT-BSS-TM-"), strings.HasPrefix(msg, "BSS-TM-"):
+		return NewOnBSSTMEvent(msg)
+	case strings.HasPrefix(msg, "ESS-DISASSOC-IMMINENT"):
+		return NewOnESSDisassocImminentEvent(msg)
+	case strings.HasPrefix(msg, "WNM-"):
+		return &OnWNMEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "RRM-NEIGHBOR-REP-RECEIVED"):
+		return NewOnNeighborReportEvent(msg)
+	case strings.HasPrefix(msg, "RRM-NEIGHBOR-REP-REQUEST-FAILED"):
+		return &OnNeighborReportFailedEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "TDLS-CONNECTED"):
+		return &OnTDLSConnectedEvent{baseEvent: baseEvent{msg}, Addr: firstMAC(msg)}
+	case strings.HasPrefix(msg, "TDLS-DISCONNECTED"):
+		return &OnTDLSDisconnectedEvent{baseEvent: baseEvent{msg}, Addr: firstMAC(msg), Reason: atoi(parseEventFields(msg)["reason"])}
+	case strings.HasPrefix(msg, "MESH-GROUP-STARTED"):
+		return NewOnMeshGroupStartedEvent(msg)
+	case strings.HasPrefix(msg, "MESH-GROUP-REMOVED"):
+		return NewOnMeshGroupRemovedEvent(msg)
+	case strings.HasPrefix(msg, "MESH-PEER-CONNECTED"):
+		return &OnMeshPeerConnectedEvent{baseEvent: baseEvent{msg}, Addr: firstMAC(msg)}
+	case strings.HasPrefix(msg, "MESH-PEER-DISCONNECTED"):
+		return &OnMeshPeerDisconnectedEvent{baseEvent: baseEvent{msg}, Addr: firstMAC(msg)}
+	case strings.HasPrefix(msg, "INTERWORKING-AP"):
+		return NewOnInterworkingAPEvent(msg)
+	case strings.HasPrefix(msg, "RX-ANQP"), strings.HasPrefix(msg, "RX-HS20-ANQP"):
+		return NewOnRxANQPEvent(msg)
+	case strings.HasPrefix(msg, "ANQP-QUERY-DONE"):
+		return NewOnANQPQueryDoneEvent(msg)
+	case strings.HasPrefix(msg, "CTRL-REQ-"):
+		return NewOnCredentialRequestEvent(msg)
+	case strings.HasPrefix(msg, "CTRL-EVENT-CONNECTED"):
+		return NewOnConnectedEvent(msg)
+	case strings.HasPrefix(msg, "CTRL-EVENT-DISCONNECTED"):
+		return NewOnDisconnectedEvent(msg)
+	case strings.HasPrefix(msg, "CTRL-EVENT-NETWORK-NOT-FOUND"):
+		return &OnNotFoundEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "CTRL-EVENT-SCAN-FAILED"):
+		return &OnScanFailedEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "CTRL-EVENT-SCAN-STARTED"):
+		return &OnScanStartedEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "CTRL-EVENT-SCAN-RESULTS"):
+		return &OnScanResultsEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "CTRL-EVENT-BSS-ADDED"):
+		return &OnScanEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "CTRL-EVENT-SIGNAL-CHANGE"):
+		return NewOnSignalChangeEvent(msg)
+	case strings.HasPrefix(msg, "CTRL-EVENT-CHANNEL-SWITCH"),
+		strings.HasPrefix(msg, "CTRL-EVENT-STARTED-CHANNEL-SWITCH"):
+		return NewOnChannelSwitchEvent(msg)
+	case strings.HasPrefix(msg, "CTRL-EVENT-EAP-STARTED"):
+		return &OnEAPStartedEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "CTRL-EVENT-EAP-METHOD"):
+		return NewOnEAPMethodEvent(msg)
+	case strings.HasPrefix(msg, "CTRL-EVENT-EAP-SUCCESS"):
+		return &OnEAPSuccessEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "CTRL-EVENT-EAP-FAILURE"):
+		return &OnEAPFailureEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "CTRL-EVENT-EAP-PEER-CERT"):
+		return NewOnEAPPeerCertEvent(msg)
+	}
+	return &OnEvent{baseEvent: baseEvent{msg}}
+}
+
+type listener struct {
+	ch     chan WPASupplicantEvent
+	filter func(WPASupplicantEvent) bool
+}
+
+// subscribe registers a channel that receives a copy of every event for which
+// filter returns true, or every event if filter is nil, in addition to
+// Events(). Sends never block; events are dropped if the channel is full, so
+// filter out events that could crowd out the ones being waited for.
+func (c *WPASupplicantCtrl) subscribe(filter func(WPASupplicantEvent) bool) chan WPASupplicantEvent {
+	ch := make(chan WPASupplicantEvent, 16)
+	c.mu.Lock()
+	c.listeners = append(c.listeners, listener{ch, filter})
+	c.mu.Unlock()
+	return ch
+}
+
+func (c *WPASupplicantCtrl) unsubscribe(ch chan WPASupplicantEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, l := range c.listeners {
+		if l.ch == ch {
+			c.listeners = append(c.listeners[:i], c.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *WPASupplicantCtrl) notify(evt WPASupplicantEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, l := range c.listeners {
+		if l.filter != nil && !l.filter(evt) {
+			continue
+		}
+		select {
+		case l.ch <- evt:
+		default:
+		}
+	}
+}
+
+// Events returns every event. Up to eventBacklog events are held while it
+// isn't read; listeners like SignalMonitor don't depend on it being read.
 func (c *WPASupplicantCtrl) Events() <-chan WPASupplicantEvent {
 	return c.events
 }
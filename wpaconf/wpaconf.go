@@ -0,0 +1,351 @@
+// Package wpaconf reads and writes wpa_supplicant.conf files.
+//
+// Comments and blank lines are kept with the setting or block that follows them,
+// so a parsed file can be written back without losing them. Network blocks use
+// the same wpa.NetworkConfig type as the control interface. Other blocks, like
+// cred={} and blob-base64-<name>={}, are kept verbatim.
+package wpaconf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	wpa "github.com/jblebrun/go-wpa"
+)
+
+// Global is a top level name=value setting.
+type Global struct {
+	// Comments are the comment and blank lines preceding the setting.
+	Comments []string
+	Name     string
+	Value    string
+}
+
+// Network is a network={} block.
+type Network struct {
+	// Comments are the comment and blank lines preceding the block.
+	Comments []string
+	*wpa.NetworkConfig
+	// VarComments holds comment lines inside the block, keyed by the index in
+	// Vars of the variable they precede.
+	VarComments map[int][]string
+	// Trailer holds comment lines between the last variable and the closing brace.
+	Trailer []string
+}
+
+// Block is a block other than network={}, such as cred={} or
+// blob-base64-<name>={}. Its contents aren't interpreted.
+type Block struct {
+	// Comments are the comment and blank lines preceding the block.
+	Comments []string
+	Name     string
+	// Lines are the lines between the braces, as they appeared in the file.
+	Lines []string
+}
+
+func (b *Block) isBlob() bool {
+	return strings.HasPrefix(b.Name, "blob-")
+}
+
+// Config is a parsed wpa_supplicant.conf.
+// Sections are written in the order wpa_supplicant writes them: globals, blocks
+// other than blobs, networks and then blobs. Within each, the order is the one
+// they were parsed or added in.
+type Config struct {
+	Globals  []*Global
+	Networks []*Network
+	Blocks   []*Block
+	// Trailer holds comment lines at the end of the file.
+	Trailer []string
+}
+
+// Parse reads a wpa_supplicant.conf.
+func Parse(r io.Reader) (*Config, error) {
+	cfg := &Config{}
+	var comments []string
+	var network *Network
+	var block *Block
+
+	scanner := bufio.NewScanner(r)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+
+		if block != nil {
+			if line == "}" {
+				cfg.Blocks = append(cfg.Blocks, block)
+				block = nil
+			} else {
+				block.Lines = append(block.Lines, scanner.Text())
+			}
+			continue
+		}
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			comments = append(comments, line)
+			continue
+		}
+
+		if network != nil {
+			if line == "}" {
+				network.Trailer = comments
+				comments = nil
+				cfg.Networks = append(cfg.Networks, network)
+				network = nil
+				continue
+			}
+			name, value, err := splitLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineno, err)
+			}
+			i := varIndex(network.NetworkConfig, name)
+			if comments != nil {
+				network.VarComments[i] = append(network.VarComments[i], comments...)
+				comments = nil
+			}
+			network.Set(name, value)
+			continue
+		}
+
+		name, value, err := splitLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+		if value == "{" {
+			if name == "network" {
+				network = &Network{
+					Comments:      comments,
+					NetworkConfig: wpa.NewNetworkConfig(),
+					VarComments:   make(map[int][]string),
+				}
+			} else {
+				block = &Block{Comments: comments, Name: name}
+			}
+			comments = nil
+			continue
+		}
+		cfg.Globals = append(cfg.Globals, &Global{Comments: comments, Name: name, Value: value})
+		comments = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if network != nil {
+		return nil, fmt.Errorf("line %d: unterminated network block", lineno)
+	}
+	if block != nil {
+		return nil, fmt.Errorf("line %d: unterminated %s block", lineno, block.Name)
+	}
+	cfg.Trailer = comments
+	return cfg, nil
+}
+
+// ParseFile reads the wpa_supplicant.conf at path.
+func ParseFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// varIndex returns the index in Vars that setting name will have.
+func varIndex(n *wpa.NetworkConfig, name string) int {
+	vars := n.Vars()
+	for i, v := range vars {
+		if v.Name == name {
+			return i
+		}
+	}
+	return len(vars)
+}
+
+func splitLine(line string) (string, string, error) {
+	i := strings.IndexByte(line, '=')
+	if i <= 0 {
+		return "", "", fmt.Errorf("invalid line %q", line)
+	}
+	return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]), nil
+}
+
+// WriteTo writes the config in wpa_supplicant.conf format.
+func (c *Config) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	for _, g := range c.Globals {
+		writeComments(&buf, "", g.Comments)
+		fmt.Fprintf(&buf, "%s=%s\n", g.Name, g.Value)
+	}
+	writeBlocks(&buf, c.Blocks, false)
+	for _, n := range c.Networks {
+		writeComments(&buf, "", n.Comments)
+		buf.WriteString("network={\n")
+		for i, v := range n.Vars() {
+			writeComments(&buf, "\t", n.VarComments[i])
+			fmt.Fprintf(&buf, "\t%s=%s\n", v.Name, v.Value)
+		}
+		writeComments(&buf, "\t", n.Trailer)
+		buf.WriteString("}\n")
+	}
+	writeBlocks(&buf, c.Blocks, true)
+	writeComments(&buf, "", c.Trailer)
+	return buf.WriteTo(w)
+}
+
+// WriteFile writes the config to path, replacing it atomically.
+func (c *Config) WriteFile(path string, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := c.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func writeBlocks(buf *bytes.Buffer, blocks []*Block, blobs bool) {
+	for _, b := range blocks {
+		if b.isBlob() != blobs {
+			continue
+		}
+		writeComments(buf, "", b.Comments)
+		fmt.Fprintf(buf, "%s={\n", b.Name)
+		for _, l := range b.Lines {
+			fmt.Fprintf(buf, "%s\n", l)
+		}
+		buf.WriteString("}\n")
+	}
+}
+
+func writeComments(buf *bytes.Buffer, indent string, comments []string) {
+	for _, c := range comments {
+		if c == "" {
+			buf.WriteString("\n")
+		} else {
+			fmt.Fprintf(buf, "%s%s\n", indent, c)
+		}
+	}
+}
+
+// Get returns the value of a global setting.
+func (c *Config) Get(name string) (string, bool) {
+	for _, g := range c.Globals {
+		if g.Name == name {
+			return g.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set sets a global setting, appending it if it isn't already present.
+func (c *Config) Set(name, value string) {
+	for _, g := range c.Globals {
+		if g.Name == name {
+			g.Value = value
+			return
+		}
+	}
+	c.Globals = append(c.Globals, &Global{Name: name, Value: value})
+}
+
+// Unset removes a global setting.
+func (c *Config) Unset(name string) {
+	for i, g := range c.Globals {
+		if g.Name == name {
+			c.Globals = append(c.Globals[:i], c.Globals[i+1:]...)
+			return
+		}
+	}
+}
+
+// AddNetwork appends a network block for cfg.
+func (c *Config) AddNetwork(cfg *wpa.NetworkConfig) *Network {
+	n := &Network{
+		NetworkConfig: cfg,
+		VarComments:   make(map[int][]string),
+	}
+	c.Networks = append(c.Networks, n)
+	return n
+}
+
+// RemoveNetwork removes the network at index i, as numbered by wpa_supplicant.
+func (c *Config) RemoveNetwork(i int) error {
+	if i < 0 || i >= len(c.Networks) {
+		return fmt.Errorf("no network %d", i)
+	}
+	c.Networks = append(c.Networks[:i], c.Networks[i+1:]...)
+	return nil
+}
+
+func (c *Config) CtrlInterface() string {
+	v, _ := c.Get("ctrl_interface")
+	return v
+}
+
+func (c *Config) SetCtrlInterface(v string) {
+	c.Set("ctrl_interface", v)
+}
+
+func (c *Config) Country() string {
+	v, _ := c.Get("country")
+	return v
+}
+
+func (c *Config) SetCountry(v string) {
+	c.Set("country", v)
+}
+
+func (c *Config) UpdateConfig() bool {
+	v, _ := c.Get("update_config")
+	return v == "1"
+}
+
+func (c *Config) SetUpdateConfig(b bool) {
+	if b {
+		c.Set("update_config", "1")
+	} else {
+		c.Set("update_config", "0")
+	}
+}
+
+// APScan returns the ap_scan setting, which defaults to 1.
+func (c *Config) APScan() int {
+	v, ok := c.Get("ap_scan")
+	if !ok {
+		return 1
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 1
+	}
+	return n
+}
+
+func (c *Config) SetAPScan(n int) {
+	c.Set("ap_scan", strconv.Itoa(n))
+}
+
+// P2P returns the p2p_* global settings.
+func (c *Config) P2P() map[string]string {
+	p2p := make(map[string]string)
+	for _, g := range c.Globals {
+		if strings.HasPrefix(g.Name, "p2p_") {
+			p2p[g.Name] = g.Value
+		}
+	}
+	return p2p
+}
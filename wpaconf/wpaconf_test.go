@@ -0,0 +1,210 @@
+package wpaconf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	wpa "github.com/jblebrun/go-wpa"
+)
+
+const sampleConf = `# managed by factory tooling
+ctrl_interface=DIR=/var/run/wpa_supplicant GROUP=netdev
+update_config=1
+country=US
+ap_scan=1
+p2p_go_intent=7
+p2p_listen_channel=6
+
+# office
+network={
+	ssid="office"
+	# passphrase
+	psk="correct horse"
+	key_mgmt=WPA-PSK
+	priority=5
+}
+
+network={
+	ssid=6e6574
+	key_mgmt=NONE
+	# open fallback
+}
+# end
+`
+
+const normalizeIn = `ctrl_interface=/var/run/wpa_supplicant
+network={
+    ssid="foo"
+  psk=0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+}
+`
+
+const normalizeOut = `ctrl_interface=/var/run/wpa_supplicant
+network={
+	ssid="foo"
+	psk=0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+}
+`
+
+func roundTrip(t *testing.T, in string) string {
+	cfg, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := cfg.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRoundTrip(t *testing.T) {
+	if out := roundTrip(t, sampleConf); out != sampleConf {
+		t.Fatalf("round trip mismatch:\n%s", out)
+	}
+}
+
+const blocksConf = `update_config=1
+
+# hotspot 2.0
+cred={
+	realm="example.com"
+	username="alice"
+	eap=TTLS
+}
+network={
+	ssid="office"
+	key_mgmt=WPA-EAP
+	ca_cert="blob://ca"
+}
+blob-base64-ca={
+MIIBszCCAVmgAwIBAgIUUFRBvOvr
+SzEfMB0GA1UdDgQWBBQ=
+}
+`
+
+func TestRoundTripBlocks(t *testing.T) {
+	if out := roundTrip(t, blocksConf); out != blocksConf {
+		t.Fatalf("round trip mismatch:\n%s", out)
+	}
+	cfg, err := Parse(strings.NewReader(blocksConf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Blocks) != 2 || cfg.Blocks[0].Name != "cred" || cfg.Blocks[1].Name != "blob-base64-ca" {
+		t.Fatalf("wrong blocks %+v", cfg.Blocks)
+	}
+	if len(cfg.Blocks[1].Lines) != 2 {
+		t.Fatal("wrong blob lines", cfg.Blocks[1].Lines)
+	}
+}
+
+func TestRepeatedVarComments(t *testing.T) {
+	in := `network={
+	# first
+	ssid="a"
+	# second
+	ssid="b"
+	# psk
+	psk="secret passphrase"
+}
+`
+	expect := `network={
+	# first
+	# second
+	ssid="b"
+	# psk
+	psk="secret passphrase"
+}
+`
+	if out := roundTrip(t, in); out != expect {
+		t.Fatalf("wrong output:\n%s", out)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if out := roundTrip(t, normalizeIn); out != normalizeOut {
+		t.Fatalf("normalize mismatch:\n%s", out)
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(sampleConf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CtrlInterface() != "DIR=/var/run/wpa_supplicant GROUP=netdev" {
+		t.Fatal("wrong ctrl_interface", cfg.CtrlInterface())
+	}
+	if cfg.Country() != "US" || !cfg.UpdateConfig() || cfg.APScan() != 1 {
+		t.Fatal("wrong globals", cfg.Country(), cfg.UpdateConfig(), cfg.APScan())
+	}
+	p2p := cfg.P2P()
+	if len(p2p) != 2 || p2p["p2p_go_intent"] != "7" {
+		t.Fatal("wrong p2p", p2p)
+	}
+}
+
+func TestNetworks(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(sampleConf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Networks) != 2 {
+		t.Fatal("wrong number of networks", len(cfg.Networks))
+	}
+	office := cfg.Networks[0]
+	if office.SSID() != "office" || office.Priority() != 5 {
+		t.Fatal("wrong network 0", office.Vars())
+	}
+	if psk, _ := office.GetString("psk"); psk != "correct horse" {
+		t.Fatal("wrong psk", psk)
+	}
+	if cfg.Networks[1].SSID() != "net" {
+		t.Fatal("wrong hex ssid", cfg.Networks[1].SSID())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := &Config{}
+	cfg.SetCtrlInterface("/var/run/wpa_supplicant")
+	cfg.SetUpdateConfig(true)
+	cfg.SetCountry("DE")
+
+	n := wpa.NewNetworkConfig()
+	n.SetSSID("factory")
+	n.SetPSK("secret passphrase")
+	n.SetKeyMgmt("WPA-PSK")
+	cfg.AddNetwork(n)
+
+	var buf bytes.Buffer
+	if _, err := cfg.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expect := `ctrl_interface=/var/run/wpa_supplicant
+update_config=1
+country=DE
+network={
+	ssid="factory"
+	psk="secret passphrase"
+	key_mgmt=WPA-PSK
+}
+`
+	if buf.String() != expect {
+		t.Fatalf("wrong output:\n%s", buf.String())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	bad := []string{
+		"network={\n\tssid=\"x\"\n",
+		"cred={\n\trealm=\"example.com\"\n",
+		"not a setting\n",
+	}
+	for _, in := range bad {
+		if _, err := Parse(strings.NewReader(in)); err == nil {
+			t.Fatalf("expected error for %q", in)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package wpatest
+
+import "strings"
+
+// SetLegacyBlacklist makes the mock behave like wpa_supplicant before 2.10,
+// which only knows BLACKLIST and not BSSID_IGNORE.
+func (w *WPAProcessMock) SetLegacyBlacklist(legacy bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.legacyBlacklist = legacy
+}
+
+// processBlacklistCommand handles BSSID_IGNORE and BLACKLIST. ok is false for
+// other commands.
+func (w *WPAProcessMock) processBlacklistCommand(fields []string) (string, bool) {
+	switch fields[0] {
+	case "BSSID_IGNORE":
+		w.mu.Lock()
+		legacy := w.legacyBlacklist
+		w.mu.Unlock()
+		if legacy {
+			return "UNKNOWN COMMAND", true
+		}
+	case "BLACKLIST":
+	default:
+		return "", false
+	}
+	if len(fields) < 2 {
+		return strings.Join(w.blacklist, "\n"), true
+	}
+	if fields[1] == "clear" {
+		w.blacklist = nil
+		return "OK", true
+	}
+	if !isMAC(fields[1]) {
+		return "FAIL", true
+	}
+	for _, b := range w.blacklist {
+		if b == fields[1] {
+			return "OK", true
+		}
+	}
+	w.blacklist = append(w.blacklist, fields[1])
+	return "OK", true
+}
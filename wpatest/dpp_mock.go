@@ -0,0 +1,68 @@
+package wpatest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DPPURI is the URI returned for every bootstrap generated by the mock.
+const DPPURI = "DPP:C:81/1;M:020000000100;K:MDkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDIgADURzxmttZoIRIPWGoQMV00XHWCAQIhXruVWOz0NjlkIA=;;"
+
+func (w *WPAProcessMock) dppID(s string) (int, bool) {
+	id, err := strconv.Atoi(s)
+	return id, err == nil && id > 0 && id <= w.dppBootstraps
+}
+
+// processDPPCommand handles the DPP_ commands. ok is false for other commands.
+func (w *WPAProcessMock) processDPPCommand(fields []string) (string, bool) {
+	switch fields[0] {
+	case "DPP_BOOTSTRAP_GEN":
+		w.dppBootstraps++
+		return strconv.Itoa(w.dppBootstraps), true
+	case "DPP_QR_CODE":
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "DPP:") {
+			return "FAIL", true
+		}
+		w.dppBootstraps++
+		return strconv.Itoa(w.dppBootstraps), true
+	case "DPP_BOOTSTRAP_GET_URI":
+		if len(fields) < 2 {
+			return "FAIL", true
+		}
+		if _, ok := w.dppID(fields[1]); !ok {
+			return "FAIL", true
+		}
+		return DPPURI, true
+	case "DPP_BOOTSTRAP_INFO":
+		if len(fields) < 2 {
+			return "FAIL", true
+		}
+		if _, ok := w.dppID(fields[1]); !ok {
+			return "FAIL", true
+		}
+		return DPPBootstrapInfoReply, true
+	case "DPP_LISTEN", "DPP_STOP_LISTEN", "DPP_AUTH_INIT":
+		return "OK", true
+	case "DPP_CONFIGURATOR_ADD":
+		w.dppConfigurators++
+		return strconv.Itoa(w.dppConfigurators), true
+	case "DPP_CONFIGURATOR_SIGN":
+		var ssid string
+		for _, f := range fields[1:] {
+			if strings.HasPrefix(f, "ssid=") {
+				ssid = strings.TrimPrefix(f, "ssid=")
+			}
+		}
+		b, err := hex.DecodeString(ssid)
+		if err != nil || len(b) == 0 {
+			return "FAIL", true
+		}
+		w.sendUnsolIfAttached("<2>DPP-CONF-RECEIVED")
+		w.sendUnsolIfAttached("<2>DPP-CONFOBJ-AKM dpp")
+		w.sendUnsolIfAttached(fmt.Sprintf("<2>DPP-CONFOBJ-SSID %s", b))
+		return "OK", true
+	}
+	return "", false
+}
@@ -0,0 +1,213 @@
+package wpatest
+
+// Canned replies returned by WPAProcessMock, captured from real devices.
+
+const SignalPollReply = `RSSI=-62
+LINKSPEED=144
+NOISE=9999
+FREQUENCY=5180
+WIDTH=80 MHz
+CENTER_FRQ1=5210
+AVG_RSSI=-60
+AVG_BEACON_RSSI=-61`
+
+// GeneratedWPSPIN is returned when the mock is asked to generate a WPS PIN.
+const GeneratedWPSPIN = "12345670"
+
+// KeyMgmtCapabilityReply is GET_CAPABILITY key_mgmt from a WPA3 capable
+// device without SAE-EXT-KEY support.
+const KeyMgmtCapabilityReply = "NONE IEEE8021X WPA-EAP WPA-PSK WPA-EAP-SHA256 WPA-PSK-SHA256 FT-PSK FT-EAP SAE FT-SAE OWE"
+
+// CapabilityReplies are the GET_CAPABILITY replies by field, from a dual band
+// 6 GHz capable device. Fields missing here reply FAIL, like erp and fips on
+// builds without them.
+var CapabilityReplies = map[string]string{
+	"eap":      "MD5 TLS MSCHAPV2 PEAP TTLS GTC OTP SIM LEAP PSK AKA AKA' FAST PAX SAKE GPSK WSC IKEV2 EKE PWD",
+	"pairwise": "CCMP-256 GCMP-256 CCMP GCMP TKIP NONE",
+	"group":    "CCMP-256 GCMP-256 CCMP GCMP TKIP",
+	"key_mgmt": KeyMgmtCapabilityReply,
+	"proto":    "RSN WPA",
+	"auth_alg": "OPEN SHARED LEAP",
+	"modes":    "IBSS AP MESH",
+	"channels": "Mode[G] Channels: 1 2 3 4 5 6 7 8 9 10 11\nMode[A] Channels: 36 40 44 48 52 56 60 64 149 153 157 161 165 1 5 9",
+	"freq": `Mode[G] Channels:
+ 1 = 2412 MHz
+ 6 = 2437 MHz
+ 11 = 2462 MHz
+Mode[A] Channels:
+ 36 = 5180 MHz
+ 52 = 5260 MHz (DFS)
+ 149 = 5745 MHz
+ 1 = 5955 MHz (NO_IR)
+ 5 = 5975 MHz (NO_IR)`,
+	"tdls": "EXTERNAL",
+	"acs":  "ACS",
+	"sae":  "H2E",
+}
+
+const PktcntPollReply = `TXGOOD=10452
+TXBAD=17
+RXGOOD=23871`
+
+const MIBReply = `dot11RSNAOptionImplemented=TRUE
+dot11RSNAPreauthenticationImplemented=TRUE
+dot11RSNAEnabled=TRUE
+dot11RSNAPreauthenticationEnabled=FALSE
+dot11RSNAConfigVersion=1
+dot11RSNAConfigPairwiseKeysSupported=5
+dot11RSNAConfigGroupCipherSize=128
+dot11RSNAConfigPMKLifetime=43200
+dot11RSNAConfigPMKReauthThreshold=70
+dot11RSNAConfigNumberOfPTKSAReplayCounters=1
+dot11RSNAConfigSATimeout=60
+dot11RSNAAuthenticationSuiteSelected=00-0f-ac-2
+dot11RSNAPairwiseCipherSelected=00-0f-ac-4
+dot11RSNAGroupCipherSelected=00-0f-ac-4
+dot11RSNAPMKIDUsed=
+dot11RSNAAuthenticationSuiteRequested=00-0f-ac-2
+dot11RSNAPairwiseCipherRequested=00-0f-ac-4
+dot11RSNAGroupCipherRequested=00-0f-ac-4
+dot11RSNAConfigNumberOfGTKSAReplayCounters=0
+dot11RSNA4WayHandshakeFailures=2
+dot1xSuppPaeState=5
+dot1xSuppHeldPeriod=60
+dot1xSuppAuthPeriod=30
+dot1xSuppStartPeriod=30
+dot1xSuppMaxStart=3
+dot1xSuppSuppControlledPortStatus=Authorized
+dot1xSuppBackendPaeState=2
+dot1xSuppEapolFramesReceived=4
+dot1xSuppEapolFramesTransmitted=4
+dot1xSuppEapolStartFramesTransmitted=0
+dot1xSuppEapolLogoffFramesTransmitted=0
+dot1xSuppEapolRespIdFramesTransmitted=0
+dot1xSuppEapolRespFramesTransmitted=0
+dot1xSuppEapolReqIdFramesReceived=0
+dot1xSuppEapolReqFramesReceived=0
+dot1xSuppInvalidEapolFramesReceived=0
+dot1xSuppEapLengthErrorFramesReceived=0
+dot1xSuppLastEapolFrameVersion=2
+dot1xSuppLastEapolFrameSource=00:1a:dd:18:a4:25`
+
+// HostapdStatusReply is formatted with the state, freq, channel, ssid and
+// number of stations.
+const HostapdStatusReply = `state=%s
+phy=phy0
+freq=%d
+num_sta_non_erp=0
+num_sta_no_short_slot_time=0
+num_sta_no_short_preamble=0
+olbc=0
+num_sta_ht_no_gf=0
+num_sta_no_ht=0
+num_sta_ht_20_mhz=0
+num_sta_ht40_intolerant=0
+olbc_ht=0
+ht_op_mode=0x0
+cac_time_seconds=0
+cac_time_left_seconds=N/A
+channel=%d
+secondary_channel=0
+ieee80211n=1
+ieee80211ac=0
+ieee80211ax=0
+beacon_int=100
+dtim_period=2
+supported_rates=02 04 0b 16 0c 12 18 24 30 48 60 6c
+max_txpower=20
+bss[0]=wlan0
+bssid[0]=02:00:00:00:03:00
+ssid[0]=%s
+num_sta[0]=%d`
+
+// StationReply is formatted with the station address.
+const StationReply = `%s
+flags=[AUTH][ASSOC][AUTHORIZED][WMM][HT]
+aid=1
+capability=0x421
+listen_interval=10
+supported_rates=82 84 8b 96 0c 12 18 24 30 48 60 6c
+timeout_next=NULLFUNC POLL
+dot11RSNAStatsSTAAddress=02:00:00:00:01:00
+dot11RSNAStatsVersion=1
+dot11RSNAStatsSelectedPairwiseCipher=00-0f-ac-4
+dot11RSNAStatsTKIPLocalMICFailures=0
+dot11RSNAStatsTKIPRemoteMICFailures=0
+wpa=2
+AKMSuiteSelector=00-0f-ac-2
+hostapd_WPAPTKState=11
+hostapd_WPAPTKGroupState=0
+rx_packets=1203
+tx_packets=980
+rx_bytes=134221
+tx_bytes=98112
+inactive_msec=120
+signal=-42
+rx_rate_info=650 mcs 7 shortGI
+tx_rate_info=650 mcs 7 shortGI
+connected_time=61`
+
+// P2PPeers are the P2P device addresses the mock knows.
+var P2PPeers = []string{"02:00:00:00:02:00", "02:00:00:00:03:00"}
+
+// P2PPeerReply is formatted with the peer address.
+const P2PPeerReply = `%s
+pri_dev_type=10-0050F204-5
+device_name=Living Room TV
+manufacturer=Example
+model_name=TV
+model_number=42
+serial_number=0001
+config_methods=0x188
+dev_capab=0x25
+group_capab=0x0
+level=-43
+age=3
+listen_freq=2437
+wps_method=not-ready
+interface_addr=02:00:00:00:02:01
+member_in_go_dev=00:00:00:00:00:00
+member_in_go_iface=00:00:00:00:00:00
+go_neg_req_sent=0
+go_state=unknown
+dialog_token=0
+intended_addr=00:00:00:00:00:00
+country=US
+oper_freq=0
+req_config_methods=0x0
+flags=[REPORTED]
+status=0
+invitation_reqs=0`
+
+const DPPBootstrapInfoReply = `type=QRCODE
+mac_addr=02:00:00:00:01:00
+info=
+num_freq=1
+use_freq=2412
+curve=prime256v1
+pkhash=5ac2f8e3d9a7b0d2c1e4f6a8b9c0d1e2f3a4b5c6d7e8f90112233445566778899`
+
+// InterworkingBSSID is the Hotspot 2.0 AP described by BSSANQPReply.
+const InterworkingBSSID = "02:00:00:00:05:00"
+
+// BSSANQPReply is BSS for InterworkingBSSID after an ANQP query, with venue
+// name, roaming consortium, NAI realm, 3GPP and operator friendly name
+// elements.
+const BSSANQPReply = `id=12
+bssid=02:00:00:00:05:00
+freq=2437
+beacon_int=100
+capabilities=0x0411
+qual=0
+noise=-92
+level=-51
+tsf=0000001234567890
+age=4
+ie=000b4578616d706c652d48533230
+flags=[WPA2-EAP-CCMP][ESS][HS20]
+ssid=Example-HS20
+anqp_venue_name=020812656e674578616d706c6520416972706f7274136672614165726f706f7274204578656d706c65
+anqp_roaming_consortium=03506f9a05001bc50460
+anqp_nai_realm=02001a00000b6578616d706c652e636f6d02051501020104050d01050101270000216d61696c2e6578616d706c652e636f6d3b636f72702e6578616d706c652e636f6d01021900
+anqp_3gpp=000900070213602032f451
+hs20_operator_friendly_name=13656e674578616d706c65204f70657261746f72`
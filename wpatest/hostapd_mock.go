@@ -0,0 +1,268 @@
+package wpatest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type station struct {
+	addr string
+}
+
+// hostapdState is the part of WPAProcessMock that emulates hostapd.
+type hostapdState struct {
+	enabled  bool
+	freq     int
+	ssid     string
+	stations []*station
+	params   map[string]string
+	acls     map[string][]string
+}
+
+// NewHostapdProcessMock is a WPAProcessMock that answers like hostapd.
+func NewHostapdProcessMock(t *testing.T, conn ListenConn) *WPAProcessMock {
+	w := &WPAProcessMock{
+		conn: conn,
+		t:    t,
+		hostapd: &hostapdState{
+			enabled: true,
+			freq:    2437,
+			ssid:    "test",
+			params:  hostapdDefaultParams(),
+			acls:    map[string][]string{},
+		},
+	}
+	go w.readLoop()
+	return w
+}
+
+// hostapdGetParams are the parameters hostapd supports in GET, with their
+// initial values.
+var hostapdGetParams = map[string]string{
+	"version":      "2.10",
+	"tls_library":  "OpenSSL 3.0.2 15 Mar 2022",
+	"wpa":          "2",
+	"wpa_key_mgmt": "WPA-PSK",
+	"wpa_group":    "CCMP",
+	"wpa_pairwise": "CCMP",
+	"rsn_pairwise": "CCMP",
+}
+
+// hostapdSetParams are the parameters the mock accepts in SET. hostapd takes
+// any configuration file parameter; this is the subset tests need.
+var hostapdSetParams = map[string]bool{
+	"ssid": true, "wpa": true, "wpa_key_mgmt": true, "wpa_pairwise": true,
+	"rsn_pairwise": true, "wpa_passphrase": true, "wpa_psk": true,
+	"sae_password": true, "ieee80211w": true, "macaddr_acl": true,
+	"ignore_broadcast_ssid": true, "max_num_sta": true, "ap_isolate": true,
+	"ap_max_inactivity": true, "beacon_int": true, "dtim_period": true,
+}
+
+func hostapdDefaultParams() map[string]string {
+	params := make(map[string]string, len(hostapdGetParams))
+	for k, v := range hostapdGetParams {
+		params[k] = v
+	}
+	return params
+}
+
+// HostapdParam returns a parameter set with SET, including ones GET doesn't
+// support.
+func (w *WPAProcessMock) HostapdParam(name string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.hostapd.params[name]
+}
+
+func (h *hostapdState) station(addr string) int {
+	for i, s := range h.stations {
+		if s.addr == addr {
+			return i
+		}
+	}
+	return -1
+}
+
+func (h *hostapdState) status() string {
+	state := "DISABLED"
+	if h.enabled {
+		state = "ENABLED"
+	}
+	return fmt.Sprintf(HostapdStatusReply, state, h.freq, freqToChannel(h.freq), h.ssid, len(h.stations))
+}
+
+func freqToChannel(freq int) int {
+	if freq < 5000 {
+		return (freq - 2407) / 5
+	}
+	return (freq - 5000) / 5
+}
+
+func stationReply(addr string) string {
+	return fmt.Sprintf(StationReply, addr)
+}
+
+// processHostapdCommand handles the hostapd specific commands. It returns false
+// for commands that should be handled like any other.
+func (w *WPAProcessMock) processHostapdCommand(fields []string) (string, bool) {
+	h := w.hostapd
+	switch fields[0] {
+	case "STATUS":
+		return h.status(), true
+	case "STA-FIRST":
+		if len(h.stations) == 0 {
+			return "", true
+		}
+		return stationReply(h.stations[0].addr), true
+	case "STA-NEXT":
+		if len(fields) < 2 {
+			return "FAIL", true
+		}
+		i := h.station(fields[1])
+		if i < 0 {
+			return "FAIL", true
+		}
+		if i+1 >= len(h.stations) {
+			return "", true
+		}
+		return stationReply(h.stations[i+1].addr), true
+	case "STA":
+		if len(fields) < 2 || h.station(fields[1]) < 0 {
+			return "FAIL", true
+		}
+		return stationReply(fields[1]), true
+	case "DEAUTHENTICATE", "DISASSOCIATE":
+		if len(fields) < 2 {
+			return "FAIL", true
+		}
+		if i := h.station(fields[1]); i >= 0 {
+			h.stations = append(h.stations[:i], h.stations[i+1:]...)
+			w.sendUnsolIfAttached("<3>AP-STA-DISCONNECTED " + fields[1])
+		}
+		return "OK", true
+	case "ENABLE":
+		if h.enabled {
+			return "FAIL", true
+		}
+		h.enabled = true
+		w.sendUnsolIfAttached("<3>AP-ENABLED ")
+		return "OK", true
+	case "DISABLE":
+		if !h.enabled {
+			return "FAIL", true
+		}
+		h.enabled = false
+		h.stations = nil
+		w.sendUnsolIfAttached("<3>AP-DISABLED ")
+		return "OK", true
+	case "RELOAD":
+		return "OK", true
+	case "WPS_PIN":
+		if len(fields) < 3 {
+			return "FAIL", true
+		}
+		return "OK", true
+	case "WPS_AP_PIN":
+		if len(fields) < 2 {
+			return "FAIL", true
+		}
+		if fields[1] == "random" {
+			return GeneratedWPSPIN, true
+		}
+		return "OK", true
+	case "CHAN_SWITCH":
+		if len(fields) < 3 || !h.enabled {
+			return "FAIL", true
+		}
+		freq, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return "FAIL", true
+		}
+		h.freq = freq
+		w.sendUnsolIfAttached(fmt.Sprintf("<3>CTRL-EVENT-CHANNEL-SWITCH freq=%d ht_enabled=1 ch_offset=0 ch_width=20 MHz cf1=%d cf2=0 dfs=0", freq, freq))
+		w.sendUnsolIfAttached(fmt.Sprintf("<3>AP-CSA-FINISHED freq=%d dfs=0", freq))
+		return "OK", true
+	case "SET":
+		if len(fields) < 3 || !hostapdSetParams[fields[1]] {
+			return "FAIL", true
+		}
+		w.mu.Lock()
+		h.params[fields[1]] = strings.Join(fields[2:], " ")
+		w.mu.Unlock()
+		if fields[1] == "ssid" {
+			h.ssid = strings.Join(fields[2:], " ")
+		}
+		return "OK", true
+	case "ACCEPT_ACL", "DENY_ACL":
+		return h.acl(fields), true
+	case "GET":
+		if len(fields) < 2 {
+			return "FAIL", true
+		}
+		if _, ok := hostapdGetParams[fields[1]]; !ok {
+			return "FAIL", true
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return h.params[fields[1]], true
+	}
+	return "", false
+}
+
+// acl emulates ACCEPT_ACL and DENY_ACL. Entries are stored as SHOW prints them.
+func (h *hostapdState) acl(fields []string) string {
+	if len(fields) < 2 {
+		return "FAIL"
+	}
+	list := h.acls[fields[0]]
+	find := func(addr string) int {
+		for i, e := range list {
+			if strings.HasPrefix(e, addr+" ") {
+				return i
+			}
+		}
+		return -1
+	}
+	switch fields[1] {
+	case "ADD_MAC":
+		if len(fields) < 3 {
+			return "FAIL"
+		}
+		vlan := "VLAN_ID=0"
+		if len(fields) > 3 {
+			vlan = fields[3]
+		}
+		if i := find(fields[2]); i >= 0 {
+			list = append(list[:i], list[i+1:]...)
+		}
+		h.acls[fields[0]] = append(list, fields[2]+" "+vlan)
+	case "DEL_MAC":
+		if len(fields) < 3 {
+			return "FAIL"
+		}
+		if i := find(fields[2]); i >= 0 {
+			h.acls[fields[0]] = append(list[:i], list[i+1:]...)
+		}
+	case "CLEAR":
+		delete(h.acls, fields[0])
+	case "SHOW":
+		return strings.Join(list, "\n")
+	default:
+		return "FAIL"
+	}
+	return "OK"
+}
+
+func (w *WPAProcessMock) sendUnsolIfAttached(msg string) {
+	if w.unsolConn != nil {
+		w.unsolConn.Write([]byte(msg))
+	}
+}
+
+// AnnounceStaConnected adds a station and sends AP-STA-CONNECTED.
+func (w *WPAProcessMock) AnnounceStaConnected(addr string) {
+	w.hostapd.stations = append(w.hostapd.stations, &station{addr: addr})
+	w.SendUnsol("<3>AP-STA-CONNECTED " + addr)
+}
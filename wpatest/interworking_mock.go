@@ -0,0 +1,96 @@
+package wpatest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type cred struct {
+	id     int
+	fields map[string]string
+}
+
+func (w *WPAProcessMock) getCred(idstr string) *cred {
+	id, err := strconv.Atoi(idstr)
+	if err != nil {
+		return nil
+	}
+	for _, c := range w.creds {
+		if c.id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// processInterworkingCommand handles the interworking, ANQP and credential
+// commands. ok is false for other commands.
+func (w *WPAProcessMock) processInterworkingCommand(fields []string) (string, bool) {
+	switch fields[0] {
+	case "ADD_CRED":
+		w.nextCred++
+		w.creds = append(w.creds, &cred{id: w.nextCred - 1, fields: map[string]string{}})
+		return strconv.Itoa(w.nextCred - 1), true
+	case "SET_CRED":
+		if len(fields) < 4 {
+			return "FAIL", true
+		}
+		c := w.getCred(fields[1])
+		if c == nil {
+			return "FAIL", true
+		}
+		c.fields[fields[2]] = strings.Trim(strings.Join(fields[3:], " "), "\"")
+		return "OK", true
+	case "REMOVE_CRED":
+		if len(fields) < 2 {
+			return "FAIL", true
+		}
+		if fields[1] == "all" {
+			w.creds = nil
+			return "OK", true
+		}
+		for i, c := range w.creds {
+			if strconv.Itoa(c.id) == fields[1] {
+				w.creds = append(w.creds[:i], w.creds[i+1:]...)
+				return "OK", true
+			}
+		}
+		return "FAIL", true
+	case "LIST_CREDS":
+		lines := []string{"cred id / realm / username / domain / imsi"}
+		sort.Slice(w.creds, func(i, j int) bool { return w.creds[i].id < w.creds[j].id })
+		for _, c := range w.creds {
+			lines = append(lines, fmt.Sprintf("%d\t%s\t%s\t%s\t%s",
+				c.id, c.fields["realm"], c.fields["username"], c.fields["domain"], c.fields["imsi"]))
+		}
+		return strings.Join(lines, "\n") + "\n", true
+	case "INTERWORKING_SELECT":
+		if len(w.creds) == 0 {
+			w.sendUnsolIfAttached("<2>INTERWORKING-NO-MATCH No network with matching credentials found")
+		} else {
+			w.sendUnsolIfAttached(fmt.Sprintf("<2>INTERWORKING-AP %s type=home id=%d priority=0 sp_priority=0",
+				InterworkingBSSID, w.creds[0].id))
+		}
+		return "OK", true
+	case "INTERWORKING_CONNECT":
+		if len(fields) < 2 || fields[1] != InterworkingBSSID || len(w.creds) == 0 {
+			return "FAIL", true
+		}
+		return "OK", true
+	case "ANQP_GET", "HS20_ANQP_GET":
+		if len(fields) < 3 || fields[1] != InterworkingBSSID {
+			return "FAIL", true
+		}
+		w.sendUnsolIfAttached("<2>RX-ANQP " + InterworkingBSSID + " Venue Name")
+		w.sendUnsolIfAttached("<2>ANQP-QUERY-DONE addr=" + InterworkingBSSID + " result=SUCCESS")
+		return "OK", true
+	case "BSS":
+		if len(fields) < 2 || fields[1] != InterworkingBSSID {
+			return "FAIL", true
+		}
+		return BSSANQPReply, true
+	}
+	return "", false
+}
@@ -0,0 +1,47 @@
+package wpatest
+
+import "fmt"
+
+// MeshIfname is the interface the mock creates for MESH_INTERFACE_ADD and
+// runs mesh groups on.
+const MeshIfname = "mesh-wlan0-0"
+
+// processMeshCommand handles the MESH_ commands. ok is false for other
+// commands.
+func (w *WPAProcessMock) processMeshCommand(fields []string) (string, bool) {
+	switch fields[0] {
+	case "MESH_INTERFACE_ADD":
+		return MeshIfname, true
+	case "MESH_GROUP_ADD":
+		if len(fields) < 2 {
+			return "FAIL", true
+		}
+		net := w.getNetworkStr(fields[1])
+		if net == nil {
+			return "FAIL", true
+		}
+		w.meshGroup = true
+		w.sendUnsolIfAttached(fmt.Sprintf(`<2>MESH-GROUP-STARTED ssid="%s" id=%d`, net.ssid, net.id))
+		return "OK", true
+	case "MESH_GROUP_REMOVE":
+		if len(fields) < 2 || fields[1] != MeshIfname || !w.meshGroup {
+			return "FAIL", true
+		}
+		w.meshGroup = false
+		w.sendUnsolIfAttached("<2>MESH-GROUP-REMOVED " + MeshIfname)
+		return "OK", true
+	case "MESH_PEER_ADD":
+		if len(fields) < 2 || !w.meshGroup {
+			return "FAIL", true
+		}
+		w.sendUnsolIfAttached("<2>MESH-PEER-CONNECTED " + fields[1])
+		return "OK", true
+	case "MESH_PEER_REMOVE":
+		if len(fields) < 2 || !w.meshGroup {
+			return "FAIL", true
+		}
+		w.sendUnsolIfAttached("<2>MESH-PEER-DISCONNECTED " + fields[1])
+		return "OK", true
+	}
+	return "", false
+}
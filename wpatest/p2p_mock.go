@@ -0,0 +1,56 @@
+package wpatest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// P2PGroupIfname is the group interface the mock creates for P2P_GROUP_ADD.
+const P2PGroupIfname = "p2p-wlan0-0"
+
+func (w *WPAProcessMock) isP2PPeer(addr string) bool {
+	for _, p := range P2PPeers {
+		if p == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// processP2PCommand handles the P2P_ commands. ok is false for other commands.
+func (w *WPAProcessMock) processP2PCommand(fields []string) (string, bool) {
+	switch fields[0] {
+	case "P2P_FIND", "P2P_STOP_FIND", "P2P_INVITE":
+		return "OK", true
+	case "P2P_PEERS":
+		return strings.Join(P2PPeers, "\n"), true
+	case "P2P_PEER":
+		if len(fields) < 2 || !w.isP2PPeer(fields[1]) {
+			return "FAIL", true
+		}
+		return fmt.Sprintf(P2PPeerReply, fields[1]), true
+	case "P2P_CONNECT":
+		if len(fields) < 3 || !w.isP2PPeer(fields[1]) {
+			return "FAIL", true
+		}
+		if fields[2] == "pin" {
+			return GeneratedWPSPIN, true
+		}
+		return "OK", true
+	case "P2P_GROUP_ADD":
+		w.p2pGroups = append(w.p2pGroups, P2PGroupIfname)
+		w.sendUnsolIfAttached("<2>P2P-GROUP-STARTED " + P2PGroupIfname +
+			` GO ssid="DIRECT-mock" freq=2412 passphrase="mockpass" go_dev_addr=02:00:00:00:00:00`)
+		return "OK", true
+	case "P2P_GROUP_REMOVE":
+		for i, g := range w.p2pGroups {
+			if len(fields) > 1 && g == fields[1] {
+				w.p2pGroups = append(w.p2pGroups[:i], w.p2pGroups[i+1:]...)
+				w.sendUnsolIfAttached("<2>P2P-GROUP-REMOVED " + g + " GO reason=REQUESTED")
+				return "OK", true
+			}
+		}
+		return "FAIL", true
+	}
+	return "", false
+}
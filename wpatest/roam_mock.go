@@ -0,0 +1,153 @@
+package wpatest
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// CurrentBSSID is the BSS the mock reports in STATUS until it roams.
+const CurrentBSSID = "02:00:00:00:01:00"
+
+// StatusReply is the supplicant STATUS reply, formatted with the current
+// bssid and its frequency.
+const StatusReply = `bssid=%s
+freq=%d
+ssid=office
+id=0
+mode=station
+pairwise_cipher=CCMP
+group_cipher=CCMP
+key_mgmt=WPA2-PSK
+wpa_state=COMPLETED
+ip_address=192.168.1.23
+p2p_device_address=02:00:00:00:00:02
+address=02:00:00:00:00:01`
+
+// ScanResultsReply is the default SCAN_RESULTS reply. ROAM only succeeds for
+// BSSes in the scan results.
+const ScanResultsReply = `bssid / frequency / signal level / flags / ssid
+02:00:00:00:01:00	2437	-72	[WPA2-PSK-CCMP][ESS]	office
+02:00:00:00:02:00	5180	-66	[WPA2-PSK-CCMP][ESS]	office
+02:00:00:00:03:00	5745	-58	[WPA2-PSK-CCMP][ESS]	guest`
+
+// NeighborReports are the RRM-NEIGHBOR-REP-RECEIVED events sent for
+// NEIGHBOR_REP_REQUEST.
+var NeighborReports = []string{
+	"bssid=02:00:00:00:01:00 info=0x8f op_class=115 chan=36 phy_type=9",
+	"bssid=02:00:00:00:02:00 info=0x8f op_class=81 chan=6 phy_type=7",
+}
+
+// SetScanResults scripts the SCAN_RESULTS reply. An empty reply restores
+// ScanResultsReply.
+func (w *WPAProcessMock) SetScanResults(rsp string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.scanResults = rsp
+}
+
+// SetSignalPoll scripts the SIGNAL_POLL reply. An empty reply restores
+// SignalPollReply.
+func (w *WPAProcessMock) SetSignalPoll(rsp string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.signalPoll = rsp
+}
+
+// SetNonSMERoam makes ROAM send a locally generated CTRL-EVENT-DISCONNECTED
+// for the current BSS before connecting to the new one, like wpa_supplicant
+// does on drivers without SME.
+func (w *WPAProcessMock) SetNonSMERoam(nonSME bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nonSMERoam = nonSME
+}
+
+func (w *WPAProcessMock) isNonSMERoam() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.nonSMERoam
+}
+
+func (w *WPAProcessMock) scanResultsReply() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.scanResults != "" {
+		return w.scanResults
+	}
+	return ScanResultsReply
+}
+
+// scanFreq returns the frequency of bssid in the scan results, or 0 if it
+// wasn't found.
+func (w *WPAProcessMock) scanFreq(bssid string) int {
+	for _, line := range strings.Split(w.scanResultsReply(), "\n")[1:] {
+		f := strings.Split(line, "\t")
+		if len(f) > 1 && strings.EqualFold(f[0], bssid) {
+			freq, _ := strconv.Atoi(f[1])
+			return freq
+		}
+	}
+	return 0
+}
+
+func isMAC(s string) bool {
+	hw, err := net.ParseMAC(s)
+	return err == nil && len(hw) == 6
+}
+
+func isUint(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 32)
+	return err == nil
+}
+
+// processRoamCommand handles the roaming and BSS table commands. ok is false
+// for other commands.
+func (w *WPAProcessMock) processRoamCommand(fields []string) (string, bool) {
+	switch fields[0] {
+	case "STATUS":
+		return fmt.Sprintf(StatusReply, w.bssid, w.scanFreq(w.bssid)), true
+	case "SCAN":
+		w.sendUnsolIfAttached("<2>CTRL-EVENT-SCAN-STARTED ")
+		for i, line := range strings.Split(w.scanResultsReply(), "\n")[1:] {
+			f := strings.Split(line, "\t")
+			w.sendUnsolIfAttached(fmt.Sprintf("<2>CTRL-EVENT-BSS-ADDED %d %s", i, f[0]))
+		}
+		w.sendUnsolIfAttached("<2>CTRL-EVENT-SCAN-RESULTS ")
+		return "OK", true
+	case "SCAN_RESULTS":
+		return w.scanResultsReply(), true
+	case "ROAM":
+		if len(fields) < 2 || !isMAC(fields[1]) || w.scanFreq(fields[1]) == 0 {
+			return "FAIL", true
+		}
+		if w.isNonSMERoam() {
+			w.sendUnsolIfAttached(fmt.Sprintf("<2>CTRL-EVENT-DISCONNECTED bssid=%s reason=3 locally_generated=1", w.bssid))
+		}
+		w.bssid = fields[1]
+		w.sendUnsolIfAttached(fmt.Sprintf("<2>CTRL-EVENT-CONNECTED - Connection to %s completed [id=0 id_str=]", w.bssid))
+		return "OK", true
+	case "BSSID":
+		if len(fields) < 3 || w.getNetworkStr(fields[1]) == nil || !isMAC(fields[2]) {
+			return "FAIL", true
+		}
+		return "OK", true
+	case "BSS_FLUSH", "BSS_EXPIRE_AGE", "BSS_EXPIRE_COUNT":
+		if len(fields) < 2 || !isUint(fields[1]) {
+			return "FAIL", true
+		}
+		return "OK", true
+	case "WNM_BSS_QUERY":
+		if len(fields) < 2 || !isUint(fields[1]) {
+			return "FAIL", true
+		}
+		return "OK", true
+	case "NEIGHBOR_REP_REQUEST":
+		for _, r := range NeighborReports {
+			w.sendUnsolIfAttached("<3>RRM-NEIGHBOR-REP-RECEIVED " + r)
+		}
+		return "OK", true
+	}
+	return "", false
+}
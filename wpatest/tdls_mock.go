@@ -0,0 +1,50 @@
+package wpatest
+
+// TDLSPeer is the station the mock reports as associated with the same AP.
+const TDLSPeer = "02:00:00:00:08:00"
+
+// processTDLSCommand handles the TDLS_ commands. ok is false for other
+// commands.
+func (w *WPAProcessMock) processTDLSCommand(fields []string) (string, bool) {
+	switch fields[0] {
+	case "TDLS_DISCOVER", "TDLS_SETUP", "TDLS_TEARDOWN", "TDLS_LINK_STATUS",
+		"TDLS_CHAN_SWITCH", "TDLS_CANCEL_CHAN_SWITCH":
+	default:
+		return "", false
+	}
+	if len(fields) < 2 {
+		return "FAIL", true
+	}
+	known := fields[1] == TDLSPeer
+	switch fields[0] {
+	case "TDLS_DISCOVER":
+		if !known {
+			return "FAIL", true
+		}
+	case "TDLS_SETUP":
+		if !known {
+			return "FAIL", true
+		}
+		w.tdlsConnected = true
+		w.sendUnsolIfAttached("<2>TDLS-CONNECTED " + TDLSPeer)
+	case "TDLS_TEARDOWN":
+		if !known || !w.tdlsConnected {
+			return "FAIL", true
+		}
+		w.tdlsConnected = false
+		w.sendUnsolIfAttached("<2>TDLS-DISCONNECTED " + TDLSPeer + " reason=3")
+	case "TDLS_LINK_STATUS":
+		switch {
+		case !known:
+			return "TDLS link status: peer does not exist", true
+		case w.tdlsConnected:
+			return "TDLS link status: connected", true
+		}
+		return "TDLS link status: peer not connected", true
+	case "TDLS_CHAN_SWITCH", "TDLS_CANCEL_CHAN_SWITCH":
+		if !known || !w.tdlsConnected {
+			return "FAIL", true
+		}
+	}
+	return "OK", true
+}
@@ -0,0 +1,82 @@
+package wpatest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"strings"
+	"sync"
+)
+
+// UDPListenConn stands in for a control interface built with
+// CONFIG_CTRL_IFACE_UDP. It answers GET_COOKIE itself and, like the real thing,
+// silently drops commands that don't carry the current cookie.
+type UDPListenConn struct {
+	*net.UDPConn
+
+	mu     sync.Mutex
+	cookie string
+}
+
+// NewUDPListenConn listens on a free localhost port.
+func NewUDPListenConn() (*UDPListenConn, error) {
+	c, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return nil, err
+	}
+	u := &UDPListenConn{UDPConn: c}
+	u.RotateCookie()
+	return u, nil
+}
+
+// Addr returns the host:port to pass to conn.NewUDPConn.
+func (u *UDPListenConn) Addr() string {
+	return u.LocalAddr().String()
+}
+
+// RotateCookie invalidates the current cookie, as a restart would.
+func (u *UDPListenConn) RotateCookie() {
+	b := make([]byte, 16)
+	rand.Read(b)
+	u.mu.Lock()
+	u.cookie = "COOKIE=" + hex.EncodeToString(b)
+	u.mu.Unlock()
+}
+
+func (u *UDPListenConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	buf := make([]byte, len(b)+64)
+	for {
+		n, addr, err := u.UDPConn.ReadFrom(buf)
+		if err != nil {
+			return 0, nil, err
+		}
+		msg := string(buf[:n])
+
+		u.mu.Lock()
+		cookie := u.cookie
+		u.mu.Unlock()
+
+		if msg == "GET_COOKIE" {
+			u.UDPConn.WriteTo([]byte(cookie), addr)
+			continue
+		}
+		if !strings.HasPrefix(msg, cookie+" ") {
+			continue
+		}
+		return copy(b, msg[len(cookie)+1:]), addr, nil
+	}
+}
+
+// Get returns a connection that sends responses to addr.
+func (u *UDPListenConn) Get(addr net.Addr) (Conn, error) {
+	return &udpReply{u.UDPConn, addr}, nil
+}
+
+type udpReply struct {
+	c    *net.UDPConn
+	addr net.Addr
+}
+
+func (r *udpReply) Write(b []byte) (int, error) {
+	return r.c.WriteTo(b, r.addr)
+}
@@ -5,6 +5,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -38,17 +39,50 @@ type WPAProcessMock struct {
 	t        *testing.T
 	conn     ListenConn
 
-	unsolConn Conn
-	networks  []*network
-	expect    *commandPair
+	unsolConn  Conn
+	networks   []*network
+	interfaces []string
+	p2pGroups  []string
+
+	dppBootstraps    int
+	dppConfigurators int
+
+	creds    []*cred
+	nextCred int
+
+	meshGroup     bool
+	tdlsConnected bool
+
+	// bssid is the BSS the mock is connected to, changed by ROAM.
+	bssid string
+
+	blacklist []string
+
+	mu     sync.Mutex
+	expect []commandPair
+	// scanResults and signalPoll replace ScanResultsReply and
+	// SignalPollReply when set.
+	scanResults string
+	signalPoll  string
+	// legacyBlacklist rejects BSSID_IGNORE, like wpa_supplicant before 2.10.
+	legacyBlacklist bool
+	// nonSMERoam makes ROAM disconnect locally before connecting.
+	nonSMERoam bool
+
+	// hostapd is set when emulating hostapd rather than wpa_supplicant
+	hostapd *hostapdState
 
 	OnNetworkEnabled func(id int)
+	// OnCtrlResponse is called with the field, network id and value of each
+	// CTRL-RSP- command.
+	OnCtrlResponse func(field string, id int, value string)
 }
 
 func NewWPAProcessMock(t *testing.T, conn ListenConn) *WPAProcessMock {
 	w := &WPAProcessMock{
-		conn: conn,
-		t:    t,
+		conn:  conn,
+		t:     t,
+		bssid: CurrentBSSID,
 	}
 	go w.readLoop()
 	return w
@@ -63,7 +97,7 @@ func (w *WPAProcessMock) getNetworkStr(idstr string) *network {
 }
 
 func (w *WPAProcessMock) getNetwork(id int) *network {
-	if id < 0 || id > len(w.networks) {
+	if id < 0 || id >= len(w.networks) {
 		return nil
 	}
 	return w.networks[id]
@@ -71,13 +105,102 @@ func (w *WPAProcessMock) getNetwork(id int) *network {
 
 // some fake implementation to help with higher level testing
 func (w *WPAProcessMock) processMockCommand(cmd string, conn Conn) string {
+	// Commands sent through the global interface are handled as if they
+	// were sent to the interface directly.
+	if strings.HasPrefix(cmd, "IFNAME=") {
+		if sp := strings.IndexByte(cmd, ' '); sp > 0 {
+			cmd = cmd[sp+1:]
+		}
+	}
+	if strings.HasPrefix(cmd, "CTRL-RSP-") {
+		return w.ctrlResponse(strings.TrimPrefix(cmd, "CTRL-RSP-"))
+	}
 	fields := strings.Split(cmd, " ")
+	if w.hostapd != nil {
+		if rsp, ok := w.processHostapdCommand(fields); ok {
+			return rsp
+		}
+	}
+	if rsp, ok := w.processP2PCommand(fields); ok {
+		return rsp
+	}
+	if rsp, ok := w.processDPPCommand(fields); ok {
+		return rsp
+	}
+	if rsp, ok := w.processInterworkingCommand(fields); ok {
+		return rsp
+	}
+	if rsp, ok := w.processMeshCommand(fields); ok {
+		return rsp
+	}
+	if rsp, ok := w.processTDLSCommand(fields); ok {
+		return rsp
+	}
+	if rsp, ok := w.processRoamCommand(fields); ok {
+		return rsp
+	}
+	if rsp, ok := w.processBlacklistCommand(fields); ok {
+		return rsp
+	}
 	switch fields[0] {
 	case "PING":
 		return "PONG"
+	case "SIGNAL_POLL":
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.signalPoll != "" {
+			return w.signalPoll
+		}
+		return SignalPollReply
+	case "GET_CAPABILITY":
+		if len(fields) < 2 {
+			return "FAIL"
+		}
+		if rsp, ok := CapabilityReplies[fields[1]]; ok {
+			return rsp
+		}
+		return "FAIL"
+	case "SIGNAL_MONITOR":
+		return "OK"
+	case "WPS_PBC", "WPS_CANCEL", "WPS_REG":
+		return "OK"
+	case "WPS_PIN":
+		if len(fields) > 2 {
+			return fields[2]
+		}
+		return GeneratedWPSPIN
+	case "PKTCNT_POLL":
+		return PktcntPollReply
+	case "MIB":
+		return MIBReply
 	case "ATTACH":
 		w.unsolConn = conn
 		return "OK"
+	case "INTERFACES":
+		return strings.Join(w.interfaces, "\n")
+	case "INTERFACE_ADD":
+		if len(fields) < 2 {
+			return "FAIL"
+		}
+		ifname := strings.Split(fields[1], "\t")[0]
+		for _, iface := range w.interfaces {
+			if iface == ifname {
+				return "FAIL"
+			}
+		}
+		w.interfaces = append(w.interfaces, ifname)
+		return "OK"
+	case "INTERFACE_REMOVE":
+		if len(fields) < 2 {
+			return "FAIL"
+		}
+		for i, iface := range w.interfaces {
+			if iface == fields[1] {
+				w.interfaces = append(w.interfaces[:i], w.interfaces[i+1:]...)
+				return "OK"
+			}
+		}
+		return "FAIL"
 	case "LIST_NETWORKS":
 		lines := []string{"network id / ssid / bssid / flags"}
 		for _, net := range w.networks {
@@ -137,6 +260,26 @@ func (w *WPAProcessMock) processMockCommand(cmd string, conn Conn) string {
 	return "UNKNOWN_COMMAND: " + fields[0]
 }
 
+// ctrlResponse handles <field>-<id>:<value>.
+func (w *WPAProcessMock) ctrlResponse(rsp string) string {
+	colon := strings.IndexByte(rsp, ':')
+	if colon < 0 {
+		return "FAIL"
+	}
+	dash := strings.LastIndexByte(rsp[:colon], '-')
+	if dash < 0 {
+		return "FAIL"
+	}
+	id, err := strconv.Atoi(rsp[dash+1 : colon])
+	if err != nil || w.getNetwork(id) == nil {
+		return "FAIL"
+	}
+	if w.OnCtrlResponse != nil {
+		go w.OnCtrlResponse(rsp[:dash], id, rsp[colon+1:])
+	}
+	return "OK"
+}
+
 func (w *WPAProcessMock) readLoop() {
 	for {
 		buf := make([]byte, 2048)
@@ -155,13 +298,12 @@ func (w *WPAProcessMock) readLoop() {
 		// If an expectation was set, then we are mocking the result,
 		// so don't process the command, just send the rsp.
 		var rsp string
-		if w.expect != nil {
-			if cmd != w.expect.cmd {
-				w.t.Errorf("cmd %s is not %s", cmd, w.expect.cmd)
+		if expect, ok := w.nextExpect(); ok {
+			if cmd != expect.cmd {
+				w.t.Errorf("cmd %s is not %s", cmd, expect.cmd)
 				return
 			}
-			rsp = w.expect.rsp
-			w.expect = nil
+			rsp = expect.rsp
 		} else {
 			rsp = w.processMockCommand(cmd, oc)
 		}
@@ -184,14 +326,26 @@ func (w *WPAProcessMock) SendUnsol(msg string) {
 	}
 }
 
+// Expect queues a mocked response. Expected commands must arrive in the order
+// they were queued; any other command fails the test.
 func (w *WPAProcessMock) Expect(cmd string, rsp string) {
-	if w.expect != nil {
-		w.t.Fatal("already expecting", w.expect)
-	}
-	w.expect = &commandPair{
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expect = append(w.expect, commandPair{
 		cmd: cmd,
 		rsp: rsp,
+	})
+}
+
+func (w *WPAProcessMock) nextExpect() (commandPair, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.expect) == 0 {
+		return commandPair{}, false
 	}
+	e := w.expect[0]
+	w.expect = w.expect[1:]
+	return e, true
 }
 
 func (w *WPAProcessMock) AnnounceConnected(id int) {
@@ -0,0 +1,206 @@
+package wpa
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// WPSPINChecksum computes the checksum digit for the first 7 digits of a WPS PIN.
+func WPSPINChecksum(pin int) int {
+	accum := 0
+	for pin > 0 {
+		accum += 3 * (pin % 10)
+		pin /= 10
+		accum += pin % 10
+		pin /= 10
+	}
+	return (10 - accum%10) % 10
+}
+
+// ValidateWPSPIN checks that pin is either a 4 digit PIN, or an 8 digit PIN
+// with a valid checksum.
+func ValidateWPSPIN(pin string) error {
+	for i := 0; i < len(pin); i++ {
+		if pin[i] < '0' || pin[i] > '9' {
+			return errors.New("WPS PIN must be digits")
+		}
+	}
+	if len(pin) != 4 && len(pin) != 8 {
+		return errors.New("WPS PIN must be 4 or 8 digits")
+	}
+	n, _ := strconv.Atoi(pin)
+	if len(pin) == 8 && WPSPINChecksum(n/10) != n%10 {
+		return errors.New("WPS PIN checksum mismatch")
+	}
+	return nil
+}
+
+// GenerateWPSPIN returns a random 8 digit PIN with a valid checksum.
+func GenerateWPSPIN() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000000))
+	if err != nil {
+		return "", err
+	}
+	pin := int(n.Int64())
+	return fmt.Sprintf("%07d%d", pin, WPSPINChecksum(pin)), nil
+}
+
+func bssidArg(bssid MAC) string {
+	if bssid.IsZero() {
+		return "any"
+	}
+	return bssid.String()
+}
+
+// WPSPBC starts push button enrollment with the AP bssid, or any AP in PBC mode
+// if bssid is zero.
+func (c *WPASupplicantCtrl) WPSPBC(bssid MAC) error {
+	if bssid.IsZero() {
+		return c.ctrl.OkCommand("WPS_PBC")
+	}
+	return c.ctrl.OkCommand(fmt.Sprintf("WPS_PBC %s", bssid))
+}
+
+// WPSPIN starts PIN enrollment with the AP bssid, or any AP if bssid is zero.
+// If pin is empty wpa_supplicant generates one. The PIN in use is returned, to
+// be entered on the registrar.
+func (c *WPASupplicantCtrl) WPSPIN(bssid MAC, pin string) (string, error) {
+	cmd := fmt.Sprintf("WPS_PIN %s", bssidArg(bssid))
+	if pin != "" {
+		if err := ValidateWPSPIN(pin); err != nil {
+			return "", err
+		}
+		cmd += " " + pin
+	}
+	return c.ctrl.FailCommand(cmd)
+}
+
+func (c *WPASupplicantCtrl) WPSCancel() error {
+	return c.ctrl.OkCommand("WPS_CANCEL")
+}
+
+// WPSNewAP are the settings WPSReg configures on the AP.
+type WPSNewAP struct {
+	SSID string
+	// Auth is OPEN, WPAPSK or WPA2PSK.
+	Auth string
+	// Encr is NONE, TKIP or CCMP.
+	Encr string
+	Key  string
+}
+
+// WPSReg acts as an external registrar for the AP bssid, using the AP's PIN.
+// With newAP set, the AP is reconfigured with those settings; otherwise the
+// supplicant just learns the AP's current settings.
+func (c *WPASupplicantCtrl) WPSReg(bssid MAC, apPIN string, newAP *WPSNewAP) error {
+	cmd := fmt.Sprintf("WPS_REG %s %s", bssidArg(bssid), apPIN)
+	if newAP != nil {
+		cmd += fmt.Sprintf(" %s %s %s %s",
+			hex.EncodeToString([]byte(newAP.SSID)),
+			newAP.Auth,
+			newAP.Encr,
+			hex.EncodeToString([]byte(newAP.Key)))
+	}
+	return c.ctrl.OkCommand(cmd)
+}
+
+// WPSPBC starts push button mode on the AP.
+func (c *HostapdCtrl) WPSPBC() error {
+	return c.ctrl.OkCommand("WPS_PBC")
+}
+
+// WPSPIN authorizes an enrollee PIN. uuid is the enrollee's UUID or "any".
+// A non-zero timeout (in seconds) limits how long the PIN is valid, and a
+// non-zero addr restricts it to that station.
+func (c *HostapdCtrl) WPSPIN(uuid, pin string, timeout int, addr MAC) error {
+	if err := ValidateWPSPIN(pin); err != nil {
+		return err
+	}
+	if uuid == "" {
+		uuid = "any"
+	}
+	cmd := fmt.Sprintf("WPS_PIN %s %s", uuid, pin)
+	if timeout != 0 || !addr.IsZero() {
+		cmd += fmt.Sprintf(" %d", timeout)
+	}
+	if !addr.IsZero() {
+		cmd += " " + addr.String()
+	}
+	return c.ctrl.OkCommand(cmd)
+}
+
+func (c *HostapdCtrl) WPSCancel() error {
+	return c.ctrl.OkCommand("WPS_CANCEL")
+}
+
+// WPSAPPin generates a random AP PIN for external registrars, valid for timeout
+// seconds (0 for hostapd's default).
+func (c *HostapdCtrl) WPSAPPin(timeout int) (string, error) {
+	cmd := "WPS_AP_PIN random"
+	if timeout != 0 {
+		cmd += fmt.Sprintf(" %d", timeout)
+	}
+	return c.ctrl.FailCommand(cmd)
+}
+
+// WPSAPPinDisable stops accepting the AP PIN.
+func (c *HostapdCtrl) WPSAPPinDisable() error {
+	return c.ctrl.OkCommand("WPS_AP_PIN disable")
+}
+
+type OnWPSPBCActiveEvent struct{ baseEvent }
+type OnWPSSuccessEvent struct{ baseEvent }
+type OnWPSTimeoutEvent struct{ baseEvent }
+type OnWPSOverlapDetectedEvent struct{ baseEvent }
+
+// OnWPSCredReceivedEvent is WPS-CRED-RECEIVED, sent when the enrollee got
+// network credentials. The new network has already been added.
+type OnWPSCredReceivedEvent struct{ baseEvent }
+
+// OnWPSFailEvent is WPS-FAIL. ConfigError is the WPS configuration error
+// (e.g. 15 for setup locked, 18 for a PIN mismatch) and Reason the
+// supplicant's failure reason, when given.
+type OnWPSFailEvent struct {
+	baseEvent
+	Msg         int
+	ConfigError int
+	Reason      int
+}
+
+func NewOnWPSFailEvent(msg string) *OnWPSFailEvent {
+	f := parseEventFields(msg)
+	return &OnWPSFailEvent{
+		baseEvent:   baseEvent{msg},
+		Msg:         atoi(f["msg"]),
+		ConfigError: atoi(f["config_error"]),
+		Reason:      atoi(f["reason"]),
+	}
+}
+
+// parseWPSEvent parses the WPS events shared by wpa_supplicant and hostapd.
+// It returns nil for anything else.
+func parseWPSEvent(msg string) WPASupplicantEvent {
+	if !strings.HasPrefix(msg, "WPS-") {
+		return nil
+	}
+	switch {
+	case strings.HasPrefix(msg, "WPS-PBC-ACTIVE"):
+		return &OnWPSPBCActiveEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "WPS-SUCCESS"):
+		return &OnWPSSuccessEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "WPS-FAIL"):
+		return NewOnWPSFailEvent(msg)
+	case strings.HasPrefix(msg, "WPS-TIMEOUT"):
+		return &OnWPSTimeoutEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "WPS-CRED-RECEIVED"):
+		return &OnWPSCredReceivedEvent{baseEvent: baseEvent{msg}}
+	case strings.HasPrefix(msg, "WPS-OVERLAP-DETECTED"):
+		return &OnWPSOverlapDetectedEvent{baseEvent: baseEvent{msg}}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package wpa
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jblebrun/go-wpa/wpatest"
+)
+
+func TestWPSPIN(t *testing.T) {
+	for _, pin := range []string{"12345670", "00000000", "1234"} {
+		if err := ValidateWPSPIN(pin); err != nil {
+			t.Fatal(pin, err)
+		}
+	}
+	for _, pin := range []string{"12345678", "1234567", "abcdefgh", "-1234567", "+123", "+1234567"} {
+		if err := ValidateWPSPIN(pin); err == nil {
+			t.Fatal("expected error for", pin)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		pin, err := GenerateWPSPIN()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateWPSPIN(pin); err != nil {
+			t.Fatal(pin, err)
+		}
+	}
+}
+
+func TestWPSEnrollee(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+
+	bssid := MustParseMAC("00:1a:dd:18:a4:25")
+	mock.Expect("WPS_PBC", "OK")
+	mock.Expect("WPS_PBC 00:1a:dd:18:a4:25", "OK")
+	if err := ctrl.WPSPBC(MAC{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.WPSPBC(bssid); err != nil {
+		t.Fatal(err)
+	}
+
+	pin, err := ctrl.WPSPIN(MAC{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin != wpatest.GeneratedWPSPIN {
+		t.Fatal("wrong pin", pin)
+	}
+	if _, err := ctrl.WPSPIN(bssid, "12345678"); err == nil {
+		t.Fatal("expected checksum error")
+	}
+
+	mock.Expect("WPS_REG 00:1a:dd:18:a4:25 12345670 6e6577 WPA2PSK CCMP 736563726574", "OK")
+	if err := ctrl.WPSReg(bssid, "12345670", &WPSNewAP{SSID: "new", Auth: "WPA2PSK", Encr: "CCMP", Key: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.WPSCancel(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWPSRegistrar(t *testing.T) {
+	mock, ctrl := NewHostapdTest(t)
+
+	mock.Expect("WPS_PIN any 12345670 120 02:00:00:00:01:00", "OK")
+	if err := ctrl.WPSPIN("", "12345670", 120, MustParseMAC("02:00:00:00:01:00")); err != nil {
+		t.Fatal(err)
+	}
+	pin, err := ctrl.WPSAPPin(300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin != wpatest.GeneratedWPSPIN {
+		t.Fatal("wrong pin", pin)
+	}
+}
+
+func TestWPSEvents(t *testing.T) {
+	mock, ctrl := NewWPASupplicantTest(t)
+	if err := ctrl.Ctrl().Attach(); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.SendUnsol("<3>WPS-FAIL msg=8 config_error=15 reason=2 (M2 Failure)")
+	select {
+	case evt := <-ctrl.Events():
+		fail, ok := evt.(*OnWPSFailEvent)
+		if !ok || fail.Msg != 8 || fail.ConfigError != 15 || fail.Reason != 2 {
+			t.Fatalf("wrong event %+v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+
+	cases := map[string]interface{}{
+		"WPS-PBC-ACTIVE":           &OnWPSPBCActiveEvent{},
+		"WPS-SUCCESS":              &OnWPSSuccessEvent{},
+		"WPS-TIMEOUT":              &OnWPSTimeoutEvent{},
+		"WPS-CRED-RECEIVED 0x100e": &OnWPSCredReceivedEvent{},
+		"WPS-OVERLAP-DETECTED":     &OnWPSOverlapDetectedEvent{},
+		"WPS-AP-AVAILABLE-PBC":     &OnEvent{},
+	}
+	for msg, expect := range cases {
+		for _, evt := range []interface{}{parseSupplicantEvent(msg), parseHostapdEvent(msg)} {
+			if fmt.Sprintf("%T", evt) != fmt.Sprintf("%T", expect) {
+				t.Fatalf("wrong event type for %s: %T", msg, evt)
+			}
+		}
+	}
+}